@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"time"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// An apngWriter writes frames of equal size to an animated PNG, which
+// loops forever. The number of frames must be known up front.
+type apngWriter struct {
+	w      io.Writer
+	frames int
+	n      int
+	seq    uint32
+	delay  time.Duration
+}
+
+func newAPNGWriter(w io.Writer, frames int, delay time.Duration) *apngWriter {
+	return &apngWriter{w: w, frames: frames, delay: delay}
+}
+
+func (a *apngWriter) chunk(typ string, data []byte) error {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(len(data)))
+	if _, err := a.w.Write(b[:]); err != nil {
+		return err
+	}
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	if _, err := io.WriteString(a.w, typ); err != nil {
+		return err
+	}
+	if _, err := a.w.Write(data); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(b[:], crc.Sum32())
+	_, err := a.w.Write(b[:])
+	return err
+}
+
+// chunks splits an encoded PNG into its chunks.
+func chunks(b []byte, f func(typ string, data []byte) error) error {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return fmt.Errorf("not a PNG")
+	}
+	b = b[len(pngSignature):]
+	for len(b) >= 12 {
+		n := int(binary.BigEndian.Uint32(b))
+		if len(b) < n+12 {
+			break
+		}
+		if err := f(string(b[4:8]), b[8:8+n]); err != nil {
+			return err
+		}
+		b = b[n+12:]
+	}
+	return nil
+}
+
+// frameControl returns the fcTL chunk of a frame of the given size.
+func (a *apngWriter) frameControl(w, h int) []byte {
+	b := make([]byte, 26)
+	binary.BigEndian.PutUint32(b[0:], a.seq)
+	binary.BigEndian.PutUint32(b[4:], uint32(w))
+	binary.BigEndian.PutUint32(b[8:], uint32(h))
+	// The delay is in milliseconds, and both offsets are left at 0.
+	binary.BigEndian.PutUint16(b[20:], uint16(a.delay/time.Millisecond))
+	binary.BigEndian.PutUint16(b[22:], 1000)
+	a.seq++
+	return b
+}
+
+func (a *apngWriter) WriteFrame(img image.Image) error {
+	if a.n == a.frames {
+		return fmt.Errorf("too many frames; want %d", a.frames)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	first := a.n == 0
+	if first {
+		if _, err := a.w.Write(pngSignature); err != nil {
+			return err
+		}
+	}
+	a.n++
+
+	size := img.Bounds().Size()
+	control := false
+	return chunks(buf.Bytes(), func(typ string, data []byte) error {
+		switch typ {
+		case "IHDR":
+			if !first {
+				return nil
+			}
+			if err := a.chunk(typ, data); err != nil {
+				return err
+			}
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl, uint32(a.frames))
+			return a.chunk("acTL", actl)
+		case "IDAT":
+			// The frame control precedes the first data chunk of a frame.
+			if !control {
+				control = true
+				if err := a.chunk("fcTL",
+					a.frameControl(size.X, size.Y)); err != nil {
+					return err
+				}
+			}
+			if first {
+				return a.chunk(typ, data)
+			}
+			fdat := make([]byte, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, a.seq)
+			copy(fdat[4:], data)
+			a.seq++
+			return a.chunk("fdAT", fdat)
+		}
+		return nil
+	})
+}
+
+// Close ends the animation, which must hold all of its frames.
+func (a *apngWriter) Close() error {
+	if a.n != a.frames {
+		return fmt.Errorf("%d frames written; want %d", a.n, a.frames)
+	}
+	return a.chunk("IEND", nil)
+}
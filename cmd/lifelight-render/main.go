@@ -0,0 +1,159 @@
+// lifelight-render runs a simulation headless and writes it to an animated
+// GIF or PNG.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lifelight/life"
+)
+
+// paletted converts img to a paletted image, with a palette of its exact
+// colors if there are few enough of them.
+func paletted(img *image.RGBA) *image.Paletted {
+	var p color.Palette
+	seen := make(map[color.RGBA]bool)
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		c := color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+		if seen[c] {
+			continue
+		}
+		if len(p) == 256 {
+			p = palette.Plan9
+			break
+		}
+		seen[c] = true
+		p = append(p, c)
+	}
+
+	pm := image.NewPaletted(img.Bounds(), p)
+	draw.Draw(pm, pm.Bounds(), img, image.Point{}, draw.Src)
+
+	return pm
+}
+
+func writeGIF(w io.Writer, frames []*image.Paletted, delay time.Duration) error {
+	g := &gif.GIF{Image: frames, Delay: make([]int, len(frames))}
+	for i := range g.Delay {
+		g.Delay[i] = int(delay / (10 * time.Millisecond))
+	}
+	return gif.EncodeAll(w, g)
+}
+
+// render writes the first n generations of e to out, as a GIF or an APNG
+// depending on its extension.
+func render(e *life.Env, c *life.Config, out string, n, scale int,
+	delay time.Duration) error {
+	ext := strings.ToLower(filepath.Ext(out))
+	if ext != ".gif" && ext != ".png" {
+		return fmt.Errorf("%s: must be a .gif or .png file", out)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := life.NewImageRenderer(c, scale)
+	var ap *apngWriter
+	var frames []*image.Paletted
+	if ext == ".png" {
+		ap = newAPNGWriter(f, n, delay)
+	}
+
+	for i := 0; i < n; i++ {
+		if i == 0 {
+			e.Draw(r)
+		} else {
+			e.Update(r)
+		}
+		if ap != nil {
+			err = ap.WriteFrame(r.Image)
+		} else {
+			frames = append(frames, paletted(r.Image))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if ap != nil {
+		err = ap.Close()
+	} else {
+		err = writeGIF(f, frames, delay)
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func main() {
+	configPath := flag.String("config", "", "load the config from this file")
+	seed := flag.Int64("seed", 0, "seed of the simulation, random if 0")
+	n := flag.Int("generations", 100, "number of generations to render")
+	scale := flag.Int("scale", 8, "size in pixels of every cell")
+	delay := flag.Duration("delay", 0,
+		"delay between generations (default 1/TicksPerSecond)")
+	out := flag.String("o", "lifelight.gif", "write to this .gif or .png file")
+	flag.Parse()
+
+	if v := os.Getenv("LIFELIGHT_DEBUG"); v != "" {
+		life.InitLogger(v)
+	}
+
+	c := life.NewConfig()
+
+	if *configPath != "" {
+		if err := c.Load(*configPath, true); err != nil {
+			log.Fatalf("config: %v\n", err)
+		}
+	}
+	if *seed != 0 {
+		c.Seed = *seed
+	}
+	if *n < 1 {
+		log.Fatalf("generations = %d; must be > 0\n", *n)
+	}
+	if *scale < 1 {
+		log.Fatalf("scale = %d; must be > 0\n", *scale)
+	}
+	if *delay == 0 {
+		*delay = time.Second / time.Duration(c.TicksPerSecond)
+	}
+
+	e := life.NewEnv(c)
+
+	if len(c.Color.Scheme) > 0 {
+		cs, err := life.ParseColorScheme(c.Color.Scheme)
+		if err != nil {
+			log.Fatalf("config: Failed to parse color: %v\n", err)
+		}
+		life.SetColorScheme(cs)
+	} else {
+		e.GenColors()
+	}
+
+	e.Reset()
+
+	if err := render(e, c, *out, *n, *scale, *delay); err != nil {
+		log.Fatalf("render: %v\n", err)
+	}
+
+	fmt.Println("seed:", e.Seed())
+	fmt.Println("rendered:", *out)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lifelight/life"
+)
+
+func TestAPNGWriter(t *testing.T) {
+	var buf bytes.Buffer
+	a := newAPNGWriter(&buf, 3, 100*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		img.Set(i, i, color.White)
+		if err := a.WriteFrame(img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.WriteFrame(image.NewRGBA(image.Rect(0, 0, 4, 4))); err == nil {
+		t.Error("WriteFrame succeeded past the number of frames")
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	var seqs []uint32
+	chunks(buf.Bytes(), func(typ string, data []byte) error {
+		types = append(types, typ)
+		if typ == "fcTL" || typ == "fdAT" {
+			seqs = append(seqs, binary.BigEndian.Uint32(data))
+		}
+		if typ == "acTL" && binary.BigEndian.Uint32(data) != 3 {
+			t.Errorf("acTL frames = %d; want 3", binary.BigEndian.Uint32(data))
+		}
+		return nil
+	})
+
+	want := []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL",
+		"fdAT", "IEND"}
+	if len(types) != len(want) {
+		t.Fatalf("chunks = %v; want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("chunks = %v; want %v", types, want)
+		}
+	}
+	for i, s := range seqs {
+		if s != uint32(i) {
+			t.Errorf("sequence numbers = %v; want 0..%d", seqs, len(seqs)-1)
+			break
+		}
+	}
+
+	// Decoders without APNG support see the first frame.
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xffff {
+		t.Error("first frame does not hold the first image")
+	}
+}
+
+func TestAPNGWriterShort(t *testing.T) {
+	a := newAPNGWriter(ioutil.Discard, 2, time.Second)
+	a.WriteFrame(image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	if err := a.Close(); err == nil {
+		t.Error("Close succeeded with missing frames")
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lifelight-render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := life.NewConfig()
+	c.Seed = 1
+	c.Hardware.MatrixWidth, c.Hardware.MatrixHeight = 16, 8
+	e := life.NewEnv(c)
+	e.Reset()
+
+	path := filepath.Join(dir, "out.gif")
+	if err := render(e, c, path, 5, 2, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 5 {
+		t.Errorf("frames = %d; want 5", len(g.Image))
+	}
+	if g.Delay[0] != 5 {
+		t.Errorf("delay = %d; want 5", g.Delay[0])
+	}
+	if s := g.Image[0].Bounds().Size(); s.X != 32 || s.Y != 16 {
+		t.Errorf("size = %v; want 32x16", s)
+	}
+
+	if err := render(e, c, filepath.Join(dir, "out.jpg"), 1, 1,
+		time.Second); err == nil {
+		t.Error("render succeeded with a .jpg file")
+	}
+}
@@ -0,0 +1,27 @@
+package life
+
+import (
+	"math"
+)
+
+// age returns the number of generations cell idx will have survived in the
+// next generation.
+func (e *Env) age(idx int) uint16 {
+	if !isLive(e.cells[idx]) || !isLive(e.buffer[idx]) {
+		return 0
+	}
+	if e.ages[idx] == math.MaxUint16 {
+		return e.ages[idx]
+	}
+	return e.ages[idx] + 1
+}
+
+// ageBrightness fades cells from full brightness when born to
+// Color.AgeDim once they reach Color.MaxAge.
+func (e *Env) ageBrightness(age uint16) float64 {
+	c := e.config.Color
+	if int(age) >= c.MaxAge {
+		return c.AgeDim
+	}
+	return 1.0 - (1.0-c.AgeDim)*float64(age)/float64(c.MaxAge)
+}
@@ -0,0 +1,46 @@
+package life
+
+import (
+	"testing"
+)
+
+func TestEnvAge(t *testing.T) {
+	e := newTestEnv(t, 4, 4, withBlank, func(c *Config) {
+		c.Color.Mode = "age"
+	})
+
+	// A block is a still life.
+	block := []int{5, 6, 9, 10}
+	for _, i := range block {
+		e.cells[i] = cellLive1
+	}
+
+	for g := 1; g <= 3; g++ {
+		e.tick()
+		for _, i := range block {
+			if e.ages[i] != uint16(g) {
+				t.Errorf("generation %d: age[%d] = %d; want %d",
+					g, i, e.ages[i], g)
+			}
+		}
+		if e.ages[0] != 0 {
+			t.Errorf("generation %d: age[0] = %d; want 0", g, e.ages[0])
+		}
+	}
+}
+
+func TestAgeBrightness(t *testing.T) {
+	e := newTestEnv(t, 4, 4, func(c *Config) {
+		c.Color.MaxAge = 10
+		c.Color.AgeDim = 0.2
+	})
+
+	ages := [...]uint16{0, 5, 10, 1000}
+	want := [...]float64{1.0, 0.6, 0.2, 0.2}
+
+	for i, a := range ages {
+		if b := e.ageBrightness(a); b < want[i]-1e-9 || b > want[i]+1e-9 {
+			t.Errorf("ageBrightness(%d) = %f; want %f", a, b, want[i])
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package life
+
+import (
+	"image/color"
+	"sort"
+)
+
+// An Automaton computes the generations of an Env, of which cells hold its
+// states, from 0 for empty cells to States()-1.
+type Automaton interface {
+	// String returns the rule of the automaton, as written in patterns.
+	String() string
+	States() int
+	// Step computes the next generation, Next(), from the current one,
+	// Cells().
+	Step(e *Env)
+	// Seed changes the next generation after every step, e.g. so that the
+	// world does not die out.
+	Seed(e *Env)
+	// Reseed sets a fraction density of the cells of the next generation
+	// to random states.
+	Reseed(e *Env, density float64)
+	// Randomize sets the cells of the current generation to random states.
+	Randomize(e *Env)
+	// Load is called once the current generation is set other than by a
+	// step, e.g. to a pattern or a rewound generation.
+	Load(e *Env)
+	// Color returns the color of cell idx in state c.
+	Color(e *Env, idx, c int) color.Color
+}
+
+var automata = map[string]func(e *Env) Automaton{
+	"life":      newLifeAutomaton,
+	"wireworld": newWireworld,
+	"turmite":   newTurmite,
+	"cyclic":    newCyclic,
+}
+
+// RegisterAutomaton makes an Automaton available to the Automaton key under
+// name, newAutomaton creating one for every Env.
+func RegisterAutomaton(name string, newAutomaton func(e *Env) Automaton) {
+	automata[name] = newAutomaton
+}
+
+func automatonNames() []string {
+	names := make([]string, 0, len(automata))
+	for n := range automata {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Automaton returns the automaton computing the generations of e.
+func (e *Env) Automaton() Automaton {
+	return e.automaton
+}
+
+// Cells returns the current generation.
+func (e *Env) Cells() Cells {
+	return e.cells
+}
+
+// Next returns the next generation, computed by steps.
+func (e *Env) Next() Cells {
+	return e.buffer
+}
+
+// lifeAutomaton runs life-like, Generations and Larger than Life rules, with
+// cells colored after their parents.
+type lifeAutomaton struct {
+	rule rule
+}
+
+func newLifeAutomaton(e *Env) Automaton {
+	c := e.config
+
+	switch c.Engine {
+	case "bitpacked":
+		e.engine = newBitEngine(e)
+	case "hashlife":
+		e.engine = newHashEngine(e)
+	default:
+		e.initNeighbors()
+		e.engine = newTableEngine(e)
+	}
+
+	// Only cells of life-like rules inherit colors or age.
+	switch c.Color.Mode {
+	case "truecolor":
+		e.colors = make([]color.RGBA, e.size)
+		e.colorBuffer = make([]color.RGBA, e.size)
+	case "age":
+		e.ages = make([]uint16, e.size)
+		e.ageBuffer = make([]uint16, e.size)
+	}
+
+	return lifeAutomaton{e.rule}
+}
+
+func (a lifeAutomaton) String() string {
+	return a.rule.String()
+}
+
+// States counts the dying states of Generations rules once for every color.
+func (a lifeAutomaton) States() int {
+	return (a.rule.states-1)*LiveCellN + 1
+}
+
+func (lifeAutomaton) Step(e *Env) {
+	e.engine.step(e)
+}
+
+func (lifeAutomaton) Seed(e *Env) {
+	e.seed()
+	e.checkStagnation()
+}
+
+func (lifeAutomaton) Reseed(e *Env, density float64) {
+	e.reseed(density)
+}
+
+func (lifeAutomaton) Randomize(e *Env) {
+	for i := range e.cells {
+		e.cells[i] = e.randomCell()
+	}
+}
+
+func (lifeAutomaton) Load(e *Env) {
+	e.engine.load(e.cells)
+}
+
+func (lifeAutomaton) Color(e *Env, idx, c int) color.Color {
+	return e.color(e.updatePalette(), idx, c)
+}
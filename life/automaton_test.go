@@ -0,0 +1,108 @@
+package life
+
+import (
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// blinker flips every cell between two states on each step.
+type blinker struct{}
+
+func (blinker) String() string { return "blinker" }
+func (blinker) States() int    { return 2 }
+func (blinker) Seed(e *Env)    {}
+func (blinker) Load(e *Env)    {}
+
+func (blinker) Step(e *Env) {
+	next := e.Next()
+	for i, c := range e.Cells() {
+		next[i] = 1 - c
+	}
+}
+
+func (blinker) Reseed(e *Env, density float64) {}
+
+func (blinker) Randomize(e *Env) {
+	for i := range e.Cells() {
+		e.Cells()[i] = e.Rand().Intn(2)
+	}
+}
+
+func (blinker) Color(e *Env, idx, c int) color.Color {
+	if c == 1 {
+		return color.White
+	}
+	return color.Black
+}
+
+func TestAutomaton(t *testing.T) {
+	RegisterAutomaton("blinker", func(e *Env) Automaton { return blinker{} })
+	defer delete(automata, "blinker")
+
+	e := newTestEnv(t, 4, 4, withHistory(10), func(c *Config) {
+		c.Automaton = "blinker"
+	})
+
+	first := append(Cells{}, e.Cells()...)
+	e.tick()
+	for i, c := range e.Cells() {
+		if c != 1-first[i] {
+			t.Fatalf("cell[%d] = %d; want %d", i, c, 1-first[i])
+		}
+	}
+
+	img := e.Image(1)
+	x, y := getCoords(0, e.width)
+	if want := uint8(255 * e.Cells()[0]); img.RGBAAt(x, y).R != want {
+		t.Errorf("pixel = %v; want %d", img.RGBAAt(x, y), want)
+	}
+
+	if !e.StepBack() || e.Cells()[0] != first[0] {
+		t.Error("failed to step back")
+	}
+	if p := e.Pattern(); p.Rule != "blinker" {
+		t.Errorf("pattern rule = %s; want blinker", p.Rule)
+	}
+}
+
+func TestLifeAutomaton(t *testing.T) {
+	e := newTestEnv(t, testWidth, testHeight, withRule("B2/S/C3"))
+
+	if s := e.Automaton().String(); s != "B2/S/C3" {
+		t.Errorf("String() = %s; want B2/S/C3", s)
+	}
+	if n := e.Automaton().States(); n != 9 {
+		t.Errorf("States() = %d; want 9", n)
+	}
+}
+
+func TestAutomatonColorMode(t *testing.T) {
+	for _, mode := range [...]string{"truecolor", "age"} {
+		e := newTestEnv(t, 8, 8, withCyclic(14, 1), withHistory(10),
+			func(c *Config) {
+				c.Color.Mode = mode
+			})
+		if e.colors != nil || e.ages != nil {
+			t.Errorf("%s: colors or ages kept for cyclic states", mode)
+		}
+		e.tick()
+		e.StepBack()
+	}
+
+	dir, err := ioutil.TempDir("", "lifelight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lifelight.ini")
+	src := "Automaton = cyclic\n[Color]\nMode = truecolor\n"
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewConfig().Load(path, true); err == nil {
+		t.Error("Load succeeded with a cyclic automaton in truecolor")
+	}
+}
@@ -0,0 +1,295 @@
+package life
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// bitEngine packs the liveness of cells into 64-bit words, one row after
+// the other, and counts the neighbors of 64 cells at once with bitwise
+// adders. The colors of live cells are kept in a compact plane that is only
+// consulted when cells are born. It supports two-state rules on the Moore
+// neighborhood of radius 1, on a torus or a bounded world.
+type bitEngine struct {
+	width    int
+	height   int
+	stride   int
+	wrap     bool
+	mask     uint64
+	birth    []int
+	survival []int
+	words    []uint64
+	next     []uint64
+	zero     []uint64
+	shifts   []bitShifts
+	colors   []uint8
+	// Functions run on the bands, made once so that steps do not allocate.
+	stepBand  func(bd *band)
+	zonesBand func(bd *band)
+}
+
+// bitShifts holds the rows above, at and below the row being computed,
+// shifted so that every bit holds its west or east neighbor.
+type bitShifts struct {
+	west [3][]uint64
+	east [3][]uint64
+}
+
+func newBitEngine(e *Env) *bitEngine {
+	stride := (e.width + 63) / 64
+
+	b := &bitEngine{
+		width:  e.width,
+		height: e.height,
+		stride: stride,
+		wrap:   e.config.Topology == "torus",
+		mask:   ^uint64(0),
+		words:  make([]uint64, stride*e.height),
+		next:   make([]uint64, stride*e.height),
+		zero:   make([]uint64, stride),
+		colors: make([]uint8, e.size),
+	}
+
+	if n := e.width % 64; n > 0 {
+		b.mask = 1<<uint(n) - 1
+	}
+	b.shifts = make([]bitShifts, len(e.bands))
+	for i := range b.shifts {
+		for j := 0; j < 3; j++ {
+			b.shifts[i].west[j] = make([]uint64, stride)
+			b.shifts[i].east[j] = make([]uint64, stride)
+		}
+	}
+	for n := range e.rule.birth {
+		if e.rule.birth[n] {
+			b.birth = append(b.birth, n)
+		}
+		if e.rule.survival[n] {
+			b.survival = append(b.survival, n)
+		}
+	}
+	b.stepBand = func(bd *band) {
+		for y := bd.first; y < bd.last; y++ {
+			b.stepRow(e, bd, y)
+			b.unpackRow(e, y)
+		}
+	}
+	b.zonesBand = func(bd *band) {
+		for y := bd.first; y < bd.last; y++ {
+			b.findDeadZones(bd, y)
+		}
+	}
+
+	return b
+}
+
+func fullAdd(a, b, c uint64) (sum, carry uint64) {
+	t := a ^ b
+	return t ^ c, a&b | t&c
+}
+
+// count8 adds eight bit planes, returning the four bits of the sums.
+func count8(a, b, c, d, e, f, g, h uint64) (s0, s1, s2, s3 uint64) {
+	x, xc := fullAdd(a, b, c)
+	y, yc := fullAdd(d, e, f)
+	z, zc := g^h, g&h
+
+	s0, c0 := fullAdd(x, y, z)
+	t, tc := fullAdd(xc, yc, zc)
+	s1 = t ^ c0
+	c1 := t & c0
+	s2 = tc ^ c1
+	s3 = tc & c1
+
+	return s0, s1, s2, s3
+}
+
+// equals returns the mask of the sums equal to n.
+func equals(n int, s [4]uint64) uint64 {
+	m := ^uint64(0)
+	for i, b := range s {
+		if n>>uint(i)&1 == 1 {
+			m &= b
+		} else {
+			m &= ^b
+		}
+	}
+	return m
+}
+
+func (b *bitEngine) row(y int) []uint64 {
+	if y < 0 || y >= b.height {
+		if !b.wrap {
+			return b.zero
+		}
+		y = mod(y, b.height)
+	}
+	return b.words[y*b.stride : (y+1)*b.stride]
+}
+
+func (b *bitEngine) bit(words []uint64, x int) uint64 {
+	return words[x>>6] >> uint(x&63) & 1
+}
+
+// shift fills west with the row shifted so that every bit holds its west
+// neighbor, and east with its east neighbor.
+func (b *bitEngine) shift(row, west, east []uint64) {
+	n := b.stride
+
+	for w := 0; w < n; w++ {
+		west[w] = row[w] << 1
+		if w > 0 {
+			west[w] |= row[w-1] >> 63
+		}
+		east[w] = row[w] >> 1
+		if w < n-1 {
+			east[w] |= row[w+1] << 63
+		}
+	}
+	west[n-1] &= b.mask
+
+	if b.wrap {
+		last := b.width - 1
+		west[0] |= b.bit(row, last)
+		east[last>>6] |= b.bit(row, 0) << uint(last&63)
+	}
+}
+
+// inherit picks the color of a cell born at x, y from its live neighbors.
+func (b *bitEngine) inherit(e *Env, x, y int, r *rand.Rand) uint8 {
+	var cs [LiveCellN]int
+
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if nx < 0 || nx >= b.width || ny < 0 || ny >= b.height {
+				if !b.wrap {
+					continue
+				}
+				nx, ny = mod(nx, b.width), mod(ny, b.height)
+			}
+			if dx == 0 && dy == 0 || b.bit(b.row(ny), nx) == 0 {
+				continue
+			}
+			cs[b.colors[getIdx(nx, ny, b.width)]-1]++
+		}
+	}
+
+	return uint8(e.inheritance.inherit(cs, r))
+}
+
+func (b *bitEngine) step(e *Env) {
+	e.parallel(b.stepBand)
+	b.words, b.next = b.next, b.words
+	e.findDeadZones(b.zonesBand)
+}
+
+func (b *bitEngine) stepRow(e *Env, bd *band, y int) {
+	sh := &b.shifts[bd.index]
+	last := b.stride - 1
+	bd.seedRow(y)
+
+	rows := [3][]uint64{b.row(y - 1), b.row(y), b.row(y + 1)}
+	for i, r := range rows {
+		b.shift(r, sh.west[i], sh.east[i])
+	}
+
+	for w, alive := range rows[1] {
+		s0, s1, s2, s3 := count8(
+			sh.west[0][w], rows[0][w], sh.east[0][w],
+			sh.west[1][w], sh.east[1][w],
+			sh.west[2][w], rows[2][w], sh.east[2][w])
+		s := [4]uint64{s0, s1, s2, s3}
+
+		var next uint64
+		for _, n := range b.birth {
+			next |= ^alive & equals(n, s)
+		}
+		for _, n := range b.survival {
+			next |= alive & equals(n, s)
+		}
+
+		valid := ^uint64(0)
+		if w == last {
+			valid = b.mask
+		}
+		b.next[y*b.stride+w] = next & valid
+
+		// Cells born in this word are dead in the current generation, so
+		// their colors can be updated in place.
+		for n := next & ^alive & valid; n != 0; n &= n - 1 {
+			x := w*64 + bits.TrailingZeros64(n)
+			b.colors[getIdx(x, y, b.width)] = b.inherit(e, x, y, bd.rand)
+		}
+	}
+}
+
+// findDeadZones finds the dead cells of row y without live neighbors, once
+// the next generation is in the words.
+func (b *bitEngine) findDeadZones(bd *band, y int) {
+	sh := &b.shifts[bd.index]
+	last := b.stride - 1
+
+	// Columns with a live cell in any of the three rows are shifted once.
+	above, at, below := b.row(y-1), b.row(y), b.row(y+1)
+	column := sh.west[0]
+	for w := range column {
+		column[w] = above[w] | at[w] | below[w]
+	}
+	b.shift(column, sh.west[1], sh.east[1])
+
+	for w, c := range column {
+		valid := ^uint64(0)
+		if w == last {
+			valid = b.mask
+		}
+		live := sh.west[1][w] | c | sh.east[1][w]
+		for z := ^live & valid; z != 0; z &= z - 1 {
+			x := w*64 + bits.TrailingZeros64(z)
+			bd.deadZones = append(bd.deadZones, getIdx(x, y, b.width))
+		}
+	}
+}
+
+// unpackRow writes a row of the next generation to the buffer of the Env.
+func (b *bitEngine) unpackRow(e *Env, y int) {
+	for w, word := range b.next[y*b.stride : (y+1)*b.stride] {
+		i := getIdx(w*64, y, b.width)
+		n := b.width - w*64
+		if n > 64 {
+			n = 64
+		}
+		buf := e.buffer[i : i+n]
+		for x := range buf {
+			buf[x] = cellDead
+			if word>>uint(x)&1 == 1 {
+				buf[x] = int(b.colors[i+x])
+			}
+			if e.ages != nil {
+				e.ageBuffer[i+x] = e.age(i + x)
+			}
+		}
+	}
+}
+
+func (b *bitEngine) load(cells Cells) {
+	for i := range b.words {
+		b.words[i] = 0
+	}
+	for i, c := range cells {
+		b.set(i, c)
+	}
+}
+
+func (b *bitEngine) set(idx, c int) {
+	x, y := getCoords(idx, b.width)
+	w := y*b.stride + x>>6
+	m := uint64(1) << uint(x&63)
+
+	if isLive(c) {
+		b.words[w] |= m
+		b.colors[idx] = uint8(c)
+	} else {
+		b.words[w] &= ^m
+	}
+}
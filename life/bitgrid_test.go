@@ -0,0 +1,125 @@
+package life
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+func TestCount8(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var ps [8]uint64
+		for j := range ps {
+			ps[j] = rand.Uint64()
+		}
+		s0, s1, s2, s3 := count8(ps[0], ps[1], ps[2], ps[3],
+			ps[4], ps[5], ps[6], ps[7])
+
+		for b := uint(0); b < 64; b++ {
+			want := 0
+			for _, p := range ps {
+				want += int(p >> b & 1)
+			}
+			n := int(s0>>b&1 | s1>>b&1<<1 | s2>>b&1<<2 | s3>>b&1<<3)
+			if n != want {
+				t.Fatalf("count8 bit %d = %d; want %d", b, n, want)
+			}
+		}
+	}
+}
+
+func TestBitEngineGlider(t *testing.T) {
+	e := newTestEnv(t, gliderWidth, gliderHeight, withEngine("bitpacked"))
+	copy(e.cells, glider0)
+	e.engine.load(e.cells)
+
+	e.tick()
+
+	for i, c := range e.cells {
+		if c != glider1[i] {
+			t.Errorf("cell = %d; want %d", c, glider1[i])
+		}
+	}
+}
+
+func TestBitEngineTable(t *testing.T) {
+	sizes := [...][2]int{{70, 20}, {64, 8}, {130, 3}, {5, 5}, {1, 4}}
+	rules := [...]string{"B3/S23", "B36/S23", "B2/S", "B3678/S34678"}
+
+	for _, size := range sizes {
+		for _, r := range rules {
+			for _, topology := range [...]string{"torus", "bounded"} {
+				e := newTestEnv(t, size[0], size[1], withRule(r),
+					withTopology(topology), withEngine("bitpacked"))
+				table := newTestEnv(t, size[0], size[1], withRule(r),
+					withTopology(topology))
+				copy(e.cells, table.cells)
+				e.engine.load(e.cells)
+
+				for g := 0; g < 30; g++ {
+					e.tick()
+					table.tick()
+
+					for i, c := range e.cells {
+						if c != table.cells[i] {
+							t.Fatalf("%s on %dx%d %s, generation %d: "+
+								"cell[%d] = %d; want %d", r, size[0],
+								size[1], topology, g, i, c, table.cells[i])
+						}
+					}
+					if len(e.deadZones) != len(table.deadZones) {
+						t.Fatalf("%s on %dx%d %s, generation %d: "+
+							"deadzones = %d; want %d", r, size[0], size[1],
+							topology, g, len(e.deadZones),
+							len(table.deadZones))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBitEngineSeed(t *testing.T) {
+	e := newTestEnv(t, 70, 10, withEngine("bitpacked"))
+	e.seedCooldownTicks = 0
+	e.seedThreshold = 0.0
+
+	for g := 0; g < 10; g++ {
+		e.tick()
+
+		b := e.engine.(*bitEngine)
+		n := 0
+		for _, w := range b.words {
+			n += bits.OnesCount64(w)
+		}
+		live := 0
+		for _, c := range e.cells {
+			if isLive(c) {
+				live++
+			}
+		}
+		if n != live {
+			t.Fatalf("generation %d: bits = %d; want %d", g, n, live)
+		}
+	}
+}
+
+func BenchmarkTickTable256(b *testing.B) {
+	e := newTestEnv(b, 256, 256, withRule("B3/S23"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.tick()
+	}
+}
+
+func BenchmarkTickBitpacked256(b *testing.B) {
+	e := newTestEnv(b, 256, 256, withEngine("bitpacked"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.tick()
+	}
+}
@@ -2,10 +2,15 @@ package life
 
 import (
 	"fmt"
+	"image/color"
 	"log"
 	"os"
+	"runtime"
 	"sort"
 	"strings"
+	"time"
+
+	"lifelight/pattern"
 
 	"github.com/go-ini/ini"
 )
@@ -16,6 +21,58 @@ var colorPalettes = []string{
 	"warm",
 }
 
+var defaultRules = map[string]string{
+	"moore":      "B3/S23",
+	"vonneumann": "B2/S013V",
+	"hex":        "B2/S34H",
+}
+
+var neighborhoodNames = map[string]neighborhood{
+	"moore":      moore,
+	"vonneumann": vonNeumann,
+	"hex":        hexagonal,
+}
+
+var inheritanceNames = []string{
+	"majority",
+	"random",
+	"minority",
+	"new",
+	"immigration",
+}
+
+var engineNames = []string{
+	"table",
+	"bitpacked",
+	"hashlife",
+}
+
+var topologyNames = []string{
+	"torus",
+	"bounded",
+	"klein",
+	"cross",
+	"mirror",
+}
+
+var colorModes = []string{
+	"palette",
+	"truecolor",
+	"age",
+}
+
+var stagnationResponses = []string{
+	"reseed",
+	"randomize",
+	"inject",
+}
+
+var resumeModes = []string{
+	"always",
+	"sameday",
+	"never",
+}
+
 var hardwareMappings = []string{
 	"regular",
 	"adafruit-hat",
@@ -33,6 +90,59 @@ type Color struct {
 	Scheme        []string
 	Palettes      []string
 	ScheduleRegen bool
+	Mode          string
+	Mutation      float64
+	MaxAge        int
+	AgeDim        float64
+}
+
+type Stagnation struct {
+	MaxPeriod int
+	Ticks     int
+	Response  string
+	Density   float64
+}
+
+type Export struct {
+	Dir   string
+	Scale int
+}
+
+type State struct {
+	File     string
+	Interval time.Duration
+	Resume   string
+}
+
+type Record struct {
+	Dir       string
+	Keyframes int
+}
+
+type History struct {
+	Generations int
+	Memory      int
+}
+
+type Control struct {
+	Fifo string
+}
+
+type Wireworld struct {
+	Scheme []string
+}
+
+type Turmite struct {
+	Rule      string
+	Ants      int
+	Highlight string
+}
+
+type Cyclic struct {
+	States       int
+	Threshold    int
+	Range        int
+	Neighborhood string
 }
 
 type Hardware struct {
@@ -43,16 +153,44 @@ type Hardware struct {
 
 type Config struct {
 	TicksPerSecond          int
+	Automaton               string
+	Rule                    string
+	Neighborhood            string
+	Topology                string
+	Inheritance             string
+	Engine                  string
+	Workers                 int
+	Seed                    int64
 	SeedThreshold           float32
 	SeedThresholdDecay      float32
 	SeedThresholdDecayTicks int
 	SeedCooldownTicks       int
+	Seeders                 []string
+	Pattern                 string
+	PatternDir              string
 	Schedule                bool
 
 	Color
+	Stagnation
+	Export
+	State
+	Record
+	History
+	Control
+	Wireworld
+	Turmite
+	Cyclic
 	Hardware
 
 	schedules map[string][]Time
+	rule      rule
+	seeders   []weightedSeeder
+	initial   *pattern.Pattern
+	patterns  []*pattern.Pattern
+
+	wireworldColors  []color.Color
+	turmite          turmiteRule
+	turmiteHighlight color.Color
 }
 
 func contains(slice []string, str string) bool {
@@ -95,14 +233,59 @@ func (t Time) String() string {
 func NewConfig() *Config {
 	c := &Config{
 		TicksPerSecond:          12,
+		Automaton:               "life",
+		Rule:                    defaultRules["moore"],
+		Neighborhood:            "moore",
+		Topology:                "torus",
+		Inheritance:             "majority",
+		Engine:                  "table",
+		Workers:                 1,
 		SeedThreshold:           0.5,
 		SeedThresholdDecay:      0.05,
 		SeedThresholdDecayTicks: 5,
 		SeedCooldownTicks:       2,
+		Seeders:                 []string{"deadzone"},
 		Schedule:                true,
 		Color: Color{
 			Palettes:      colorPalettes,
 			ScheduleRegen: true,
+			Mode:          "palette",
+			Mutation:      0.02,
+			MaxAge:        64,
+			AgeDim:        0.15,
+		},
+		Stagnation: Stagnation{
+			Ticks:    60,
+			Response: "reseed",
+			Density:  0.1,
+		},
+		Export: Export{
+			Dir:   "/var/lib/lifelight/export",
+			Scale: 8,
+		},
+		State: State{
+			Interval: 10 * time.Minute,
+			Resume:   "always",
+		},
+		Record: Record{
+			Keyframes: 600,
+		},
+		History: History{
+			Memory: 1024,
+		},
+		Wireworld: Wireworld{
+			Scheme: []string{"#000000", "#0080ff", "#ffffff", "#ff8000"},
+		},
+		Turmite: Turmite{
+			Rule:      "RL",
+			Ants:      1,
+			Highlight: "#ffffff",
+		},
+		Cyclic: Cyclic{
+			States:       14,
+			Threshold:    1,
+			Range:        1,
+			Neighborhood: "moore",
 		},
 		Hardware: Hardware{
 			MatrixWidth:  32,
@@ -111,6 +294,11 @@ func NewConfig() *Config {
 		},
 	}
 	c.schedules = make(map[string][]Time)
+	c.rule, _ = parseRule(c.Rule)
+	c.seeders, _ = parseSeeders(c.Seeders)
+	c.wireworldColors, _ = parseColors(c.Wireworld.Scheme)
+	c.turmite, _ = parseTurmite(c.Turmite.Rule)
+	c.turmiteHighlight = color.White
 
 	return c
 }
@@ -172,6 +360,73 @@ sections:
 	}
 }
 
+// loadRule parses the rule, falling back to the default rule of the
+// configured neighborhood if no rule is given.
+func (c *Config) loadRule(f *ini.File) (err error) {
+	nh, ok := neighborhoodNames[c.Neighborhood]
+	if !ok {
+		names := make([]string, 0, len(neighborhoodNames))
+		for n := range neighborhoodNames {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("Neighborhood = %s; must be one of: %s",
+			c.Neighborhood, strings.Join(names, ", "))
+	}
+
+	if !f.Section("").HasKey("Rule") {
+		c.Rule = defaultRules[c.Neighborhood]
+	}
+	if c.rule, err = parseRule(c.Rule); err != nil {
+		return fmt.Errorf("Rule = %s; %v", c.Rule, err)
+	}
+
+	if f.Section("").HasKey("Neighborhood") && c.rule.neighborhood != nh {
+		return fmt.Errorf("Rule = %s; does not match Neighborhood = %s",
+			c.Rule, c.Neighborhood)
+	}
+
+	return nil
+}
+
+// checkEngine ensures the engine supports the rule, topology and color mode.
+func (c *Config) checkEngine() error {
+	if !contains(engineNames, c.Engine) {
+		return fmt.Errorf("Engine = %s; must be one of: %s",
+			c.Engine, strings.Join(engineNames, ", "))
+	}
+	if c.Engine == "table" {
+		return nil
+	}
+
+	r := c.rule
+	if r.radius != 1 || r.neighborhood != moore || r.states != 2 ||
+		r.middle {
+		return fmt.Errorf("Engine = %s; Rule = %s; must be a two-state "+
+			"rule on the Moore neighborhood", c.Engine, c.Rule)
+	}
+	// Hashlife assumes that empty space stays empty.
+	if c.Engine == "hashlife" && r.birth[0] {
+		return fmt.Errorf("Engine = %s; Rule = %s; must not have births "+
+			"on 0 neighbors", c.Engine, c.Rule)
+	}
+	if c.Engine == "hashlife" && c.Topology != "torus" {
+		return fmt.Errorf("Engine = %s; Topology = %s; must be torus, the "+
+			"world being unbounded", c.Engine, c.Topology)
+	}
+	if c.Engine == "bitpacked" && c.Topology != "torus" &&
+		c.Topology != "bounded" {
+		return fmt.Errorf("Engine = %s; Topology = %s; must be torus or "+
+			"bounded", c.Engine, c.Topology)
+	}
+	if c.Color.Mode == "truecolor" {
+		return fmt.Errorf("Engine = %s; Color.Mode = %s; not supported",
+			c.Engine, c.Color.Mode)
+	}
+
+	return nil
+}
+
 func (c *Config) Load(path string, mustExist bool) error {
 	if _, err := os.Stat(path); err != nil {
 		if mustExist {
@@ -198,6 +453,24 @@ func (c *Config) Load(path string, mustExist bool) error {
 		return fmt.Errorf("TicksPerSecond = %d; must be > 0",
 			c.TicksPerSecond)
 	}
+	if _, ok := automata[c.Automaton]; !ok {
+		return fmt.Errorf("Automaton = %s; must be one of: %s",
+			c.Automaton, strings.Join(automatonNames(), ", "))
+	}
+	if err = c.loadRule(f); err != nil {
+		return err
+	}
+	if !contains(topologyNames, c.Topology) {
+		return fmt.Errorf("Topology = %s; must be one of: %s",
+			c.Topology, strings.Join(topologyNames, ", "))
+	}
+	if !contains(inheritanceNames, c.Inheritance) {
+		return fmt.Errorf("Inheritance = %s; must be one of: %s",
+			c.Inheritance, strings.Join(inheritanceNames, ", "))
+	}
+	if c.Workers < 0 {
+		return fmt.Errorf("Workers = %d; must be positive", c.Workers)
+	}
 	if c.SeedThreshold > 1.0 || c.SeedThreshold < 0.0 {
 		return fmt.Errorf("SeedThreshold = %f; must be in range [0.0, 1.0]",
 			c.SeedThreshold)
@@ -214,6 +487,28 @@ func (c *Config) Load(path string, mustExist bool) error {
 		return fmt.Errorf("SeedCooldownTicks = %d; must be positive",
 			c.SeedCooldownTicks)
 	}
+	if c.seeders, err = parseSeeders(c.Seeders); err != nil {
+		return fmt.Errorf("Seeders = %s; %v", strings.Join(c.Seeders, ", "),
+			err)
+	}
+
+	if c.PatternDir != "" {
+		if err = c.loadPatternDir(); err != nil {
+			return fmt.Errorf("PatternDir = %s; %v", c.PatternDir, err)
+		}
+	}
+	switch c.Pattern {
+	case "":
+	case "random":
+		if len(c.patterns) == 0 {
+			return fmt.Errorf("Pattern = random; PatternDir = %s; "+
+				"has no patterns", c.PatternDir)
+		}
+	default:
+		if c.initial, err = c.loadPattern(c.Pattern); err != nil {
+			return fmt.Errorf("Pattern = %s; %v", c.Pattern, err)
+		}
+	}
 
 	n := len(c.Color.Scheme)
 	if n > 0 && n < 4 {
@@ -227,6 +522,87 @@ func (c *Config) Load(path string, mustExist bool) error {
 		}
 	}
 
+	if !contains(colorModes, c.Color.Mode) {
+		return fmt.Errorf("Color.Mode = %s; must be one of: %s",
+			c.Color.Mode, strings.Join(colorModes, ", "))
+	}
+	if c.Automaton != "life" && c.Color.Mode != "palette" {
+		return fmt.Errorf("Automaton = %s; Color.Mode = %s; must be palette",
+			c.Automaton, c.Color.Mode)
+	}
+	if c.Color.Mutation > 1.0 || c.Color.Mutation < 0.0 {
+		return fmt.Errorf("Color.Mutation = %f; must be in range [0.0, 1.0]",
+			c.Color.Mutation)
+	}
+	if c.Color.MaxAge < 1 {
+		return fmt.Errorf("Color.MaxAge = %d; must be > 0", c.Color.MaxAge)
+	}
+	if c.Color.AgeDim > 1.0 || c.Color.AgeDim < 0.0 {
+		return fmt.Errorf("Color.AgeDim = %f; must be in range [0.0, 1.0]",
+			c.Color.AgeDim)
+	}
+
+	if c.Stagnation.MaxPeriod < 0 {
+		return fmt.Errorf("Stagnation.MaxPeriod = %d; must be positive",
+			c.Stagnation.MaxPeriod)
+	}
+	if c.Stagnation.Ticks < 0 {
+		return fmt.Errorf("Stagnation.Ticks = %d; must be positive",
+			c.Stagnation.Ticks)
+	}
+	if !contains(stagnationResponses, c.Stagnation.Response) {
+		return fmt.Errorf("Stagnation.Response = %s; must be one of: %s",
+			c.Stagnation.Response, strings.Join(stagnationResponses, ", "))
+	}
+	if c.Stagnation.Density > 1.0 || c.Stagnation.Density < 0.0 {
+		return fmt.Errorf("Stagnation.Density = %f; must be in range "+
+			"[0.0, 1.0]", c.Stagnation.Density)
+	}
+
+	if c.Export.Scale < 1 {
+		return fmt.Errorf("Export.Scale = %d; must be > 0", c.Export.Scale)
+	}
+
+	if c.State.Interval < 0 {
+		return fmt.Errorf("State.Interval = %v; must be positive",
+			c.State.Interval)
+	}
+	if !contains(resumeModes, c.State.Resume) {
+		return fmt.Errorf("State.Resume = %s; must be one of: %s",
+			c.State.Resume, strings.Join(resumeModes, ", "))
+	}
+
+	if c.Record.Keyframes < 1 {
+		return fmt.Errorf("Record.Keyframes = %d; must be > 0",
+			c.Record.Keyframes)
+	}
+
+	if c.History.Generations < 0 {
+		return fmt.Errorf("History.Generations = %d; must be >= 0",
+			c.History.Generations)
+	}
+
+	if c.History.Memory < 1 {
+		return fmt.Errorf("History.Memory = %d; must be > 0", c.History.Memory)
+	}
+
+	if n := len(c.Wireworld.Scheme); n != wireworldStates {
+		return fmt.Errorf("Wireworld.Scheme length = %d; must be %d", n,
+			wireworldStates)
+	}
+	if c.wireworldColors, err = parseColors(c.Wireworld.Scheme); err != nil {
+		return fmt.Errorf("Wireworld.Scheme = %s; %v",
+			strings.Join(c.Wireworld.Scheme, ", "), err)
+	}
+
+	if err = c.checkTurmite(); err != nil {
+		return err
+	}
+
+	if err = c.checkCyclic(); err != nil {
+		return err
+	}
+
 	if c.Hardware.MatrixWidth < 1 {
 		return fmt.Errorf("Hardware.MatrixWidth = %d; must be > 0",
 			c.Hardware.MatrixWidth)
@@ -240,11 +616,97 @@ func (c *Config) Load(path string, mustExist bool) error {
 			c.Hardware.Mapping, strings.Join(hardwareMappings, ", "))
 	}
 
+	if err = c.checkEngine(); err != nil {
+		return err
+	}
+
+	if c.hexagonal() {
+		if c.Hardware.MatrixWidth < 2 {
+			return fmt.Errorf("Hardware.MatrixWidth = %d; must be > 1 "+
+				"for hexagonal rules", c.Hardware.MatrixWidth)
+		}
+		if c.Hardware.MatrixHeight%2 != 0 && c.Topology != "bounded" &&
+			c.Topology != "mirror" {
+			return fmt.Errorf("Hardware.MatrixHeight = %d; must be even "+
+				"for hexagonal rules on a %s", c.Hardware.MatrixHeight,
+				c.Topology)
+		}
+	}
+
 	c.loadSchedules(f)
 
 	return nil
 }
 
+// checkCyclic ensures cyclic automata have neighbors enough to advance.
+// checkTurmite parses the turmite rule and highlight. Ants live outside of
+// the cells, so turmites are neither rewound nor saved.
+func (c *Config) checkTurmite() error {
+	var err error
+	if c.turmite, err = parseTurmite(c.Turmite.Rule); err != nil {
+		return fmt.Errorf("Turmite.Rule = %s; %v", c.Turmite.Rule, err)
+	}
+	if c.Turmite.Ants < 1 {
+		return fmt.Errorf("Turmite.Ants = %d; must be > 0", c.Turmite.Ants)
+	}
+	hl, err := parseColors([]string{c.Turmite.Highlight})
+	if err != nil {
+		return fmt.Errorf("Turmite.Highlight = %s; %v", c.Turmite.Highlight,
+			err)
+	}
+	c.turmiteHighlight = hl[0]
+
+	if c.Automaton != "turmite" {
+		return nil
+	}
+	if c.History.Generations > 0 {
+		return fmt.Errorf("History.Generations = %d; must be 0 for turmites",
+			c.History.Generations)
+	}
+	if c.State.File != "" {
+		return fmt.Errorf("State.File = %s; must be unset for turmites",
+			c.State.File)
+	}
+	return nil
+}
+
+func (c *Config) checkCyclic() error {
+	cc := c.Cyclic
+	nh, ok := neighborhoodNames[cc.Neighborhood]
+	if !ok || nh == hexagonal {
+		return fmt.Errorf("Cyclic.Neighborhood = %s; must be moore or "+
+			"vonneumann", cc.Neighborhood)
+	}
+	if cc.States < 2 {
+		return fmt.Errorf("Cyclic.States = %d; must be > 1", cc.States)
+	}
+	if cc.Range < 1 || cc.Range > maxRadius {
+		return fmt.Errorf("Cyclic.Range = %d; must be in range [1, %d]",
+			cc.Range, maxRadius)
+	}
+	if n := len(nh.offsets(cc.Range)[0]); cc.Threshold < 1 ||
+		cc.Threshold > n {
+		return fmt.Errorf("Cyclic.Threshold = %d; must be in range [1, %d]",
+			cc.Threshold, n)
+	}
+	return nil
+}
+
+// hexagonal reports whether cells are hexagonal, which only life rules
+// support.
+func (c *Config) hexagonal() bool {
+	return c.Automaton == "life" && c.rule.neighborhood == hexagonal
+}
+
+// workers returns the number of workers computing generations, all CPUs
+// being used if Workers is 0.
+func (c *Config) workers() int {
+	if c.Workers == 0 {
+		return runtime.NumCPU()
+	}
+	return c.Workers
+}
+
 func (c *Config) HasSchedule(d string) bool {
 	_, ok := c.schedules[d]
 	return ok
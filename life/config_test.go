@@ -172,3 +172,47 @@ func TestConfigGetScheduleState(t *testing.T) {
 		}
 	}
 }
+
+func testLoadRule(t *testing.T, src string) (*Config, error) {
+	f, _ := ini.Load([]byte(src))
+
+	c := NewConfig()
+	if err := f.MapTo(c); err != nil {
+		t.Fatal(err)
+	}
+
+	return c, c.loadRule(f)
+}
+
+func TestConfigLoadRule(t *testing.T) {
+	configs := map[string]string{
+		"":                                "B3/S23",
+		"Neighborhood = hex":              "B2/S34H",
+		"Neighborhood = vonneumann":       "B2/S013V",
+		"Rule = B36/S23":                  "B36/S23",
+		"Rule = B2/S3H\nNeighborhood=hex": "B2/S3H",
+	}
+
+	for src, want := range configs {
+		c, err := testLoadRule(t, src)
+		if err != nil {
+			t.Errorf("loadRule(%q): %v", src, err)
+			continue
+		}
+		if r := c.rule.String(); r != want {
+			t.Errorf("loadRule(%q) = %s; want %s", src, r, want)
+		}
+	}
+}
+
+func TestConfigLoadRuleInvalid(t *testing.T) {
+	for _, src := range [...]string{
+		"Rule = B3",
+		"Neighborhood = triangle",
+		"Rule = B2/S34H\nNeighborhood = moore",
+	} {
+		if _, err := testLoadRule(t, src); err == nil {
+			t.Errorf("loadRule(%q) = nil; want error", src)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package life
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Command is read from the control FIFO, one per line, e.g. "back 10".
+type Command struct {
+	Name string
+	N    int
+}
+
+// Commands and the default value of their argument, or -1 if they take
+// none:
+//
+//	pause:        stop ticking.
+//	play:         carry on ticking, replaying rewound generations first.
+//	back N:       pause and step back N generations.
+//	forward N:    pause and replay N rewound generations.
+//	rewind N:     step back N seconds and play.
+//	reseed:       reseed the next generation and play, dropping the rewound
+//	              generations.
+//	advance N:    jump N generations ahead, without seeding.
+var commands = map[string]int{
+	"pause":   -1,
+	"play":    -1,
+	"back":    1,
+	"forward": 1,
+	"rewind":  60,
+	"reseed":  -1,
+	"advance": 1000,
+}
+
+func ParseCommand(line string) (Command, error) {
+	fs := strings.Fields(line)
+	if len(fs) == 0 {
+		return Command{}, fmt.Errorf("empty command")
+	}
+
+	cmd := Command{Name: fs[0]}
+	n, ok := commands[cmd.Name]
+	if !ok {
+		return cmd, fmt.Errorf("unknown command '%s'", cmd.Name)
+	}
+
+	switch {
+	case len(fs) == 1:
+		cmd.N = n
+	case n < 0 || len(fs) > 2:
+		return cmd, fmt.Errorf("%s: too many arguments", cmd.Name)
+	default:
+		var err error
+		if cmd.N, err = strconv.Atoi(fs[1]); err != nil || cmd.N < 1 {
+			return cmd, fmt.Errorf("%s: %s; must be a number > 0", cmd.Name,
+				fs[1])
+		}
+	}
+
+	return cmd, nil
+}
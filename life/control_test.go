@@ -0,0 +1,31 @@
+package life
+
+import (
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	for line, want := range map[string]Command{
+		"pause":        {"pause", -1},
+		" back ":       {"back", 1},
+		"back 200":     {"back", 200},
+		"rewind":       {"rewind", 60},
+		"forward\t12 ": {"forward", 12},
+		"advance":      {"advance", 1000},
+		"advance 1024": {"advance", 1024},
+	} {
+		cmd, err := ParseCommand(line)
+		if err != nil {
+			t.Errorf("ParseCommand(%q): %v", line, err)
+		} else if cmd != want {
+			t.Errorf("ParseCommand(%q) = %v; want %v", line, cmd, want)
+		}
+	}
+
+	for _, line := range [...]string{"", "jump", "pause 2", "back 0",
+		"back x", "back 1 2"} {
+		if _, err := ParseCommand(line); err == nil {
+			t.Errorf("ParseCommand(%q) succeeded", line)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// cyclic runs cyclic cellular automata: cells advance to the next state,
+// wrapping around to the first, once at least Threshold of their neighbors
+// within Range are in that state.
+type cyclic struct {
+	states    int
+	threshold int
+	radius    int
+	nh        neighborhood
+	palette   []color.Color
+	scheme    ColorScheme
+}
+
+func newCyclic(e *Env) Automaton {
+	c := e.config.Cyclic
+	a := &cyclic{
+		states:    c.States,
+		threshold: c.Threshold,
+		radius:    c.Range,
+		nh:        neighborhoodNames[c.Neighborhood],
+	}
+
+	e.offsets = a.nh.offsets(a.radius)
+	e.initNeighbors()
+
+	return a
+}
+
+// String returns the rule in the notation of MCell, e.g. R1/T1/C14/NM.
+func (a *cyclic) String() string {
+	n := "NM"
+	if a.nh == vonNeumann {
+		n = "NN"
+	}
+	return fmt.Sprintf("R%d/T%d/C%d/%s", a.radius, a.threshold, a.states, n)
+}
+
+func (a *cyclic) States() int {
+	return a.states
+}
+
+func (a *cyclic) Step(e *Env) {
+	e.parallel(func(b *band) {
+		first, last := b.cells(e.width)
+
+		for i := first; i < last; i++ {
+			c := e.cells[i]
+			next := (c + 1) % a.states
+			e.buffer[i] = c
+
+			n := 0
+			for _, j := range e.getNeighbors(i) {
+				if j >= 0 && e.cells[j] == next {
+					if n++; n >= a.threshold {
+						e.buffer[i] = next
+						break
+					}
+				}
+			}
+		}
+	})
+}
+
+// Seed does nothing, cyclic automata never dying out.
+func (a *cyclic) Seed(e *Env) {
+}
+
+// Reseed sets a fraction density of the cells to random states.
+func (a *cyclic) Reseed(e *Env, density float64) {
+	for i := range e.buffer {
+		if e.rand.Float64() < density {
+			e.buffer[i] = e.rand.Intn(a.states)
+		}
+	}
+}
+
+func (a *cyclic) Randomize(e *Env) {
+	for i := range e.cells {
+		e.cells[i] = e.rand.Intn(a.states)
+	}
+}
+
+func (a *cyclic) Load(e *Env) {
+}
+
+// Color maps states to colors evenly spaced around a gradient through the
+// live colors of the color scheme, so that the last state blends into the
+// first.
+func (a *cyclic) Color(e *Env, idx, c int) color.Color {
+	if a.palette == nil || a.scheme != colorScheme {
+		a.palette = gradient(colorScheme[cellLive1:], a.states, true)
+		a.scheme = colorScheme
+	}
+	return a.palette[c]
+}
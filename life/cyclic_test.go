@@ -0,0 +1,83 @@
+package life
+
+import (
+	"image/color"
+	"testing"
+)
+
+func withCyclic(states, threshold int) func(c *Config) {
+	return func(c *Config) {
+		c.Automaton = "cyclic"
+		c.Cyclic.States = states
+		c.Cyclic.Threshold = threshold
+	}
+}
+
+func TestCyclicStep(t *testing.T) {
+	for _, threshold := range [...]int{1, 2} {
+		e := newTestEnv(t, 5, 5, withCyclic(3, threshold))
+		if s := e.automaton.String(); threshold == 1 && s != "R1/T1/C3/NM" {
+			t.Errorf("rule = %s; want R1/T1/C3/NM", s)
+		}
+		for i := range e.cells {
+			e.cells[i] = 0
+		}
+		e.cells[getIdx(2, 2, e.width)] = 1
+		e.load()
+		e.tick()
+
+		n := 0
+		for _, c := range e.cells {
+			n += c
+		}
+		// The neighbors of the cell in state 1 advance to it, which stays
+		// in it, having no neighbor in state 2.
+		if want := map[int]int{1: 9, 2: 1}[threshold]; n != want {
+			t.Errorf("threshold %d: cells in state 1 = %d; want %d",
+				threshold, n, want)
+		}
+	}
+}
+
+func TestCyclicNeverDies(t *testing.T) {
+	e := newTestEnv(t, 32, 32, withCyclic(14, 1))
+	for g := 0; g < 300; g++ {
+		e.tick()
+	}
+
+	prev := append(Cells{}, e.cells...)
+	e.tick()
+	if equalCells(prev, e.cells) {
+		t.Error("world stopped changing")
+	}
+}
+
+func TestCyclicColor(t *testing.T) {
+	e := newTestEnv(t, 4, 4, withCyclic(6, 1))
+	seen := make(map[color.Color]bool)
+	for c := 0; c < 6; c++ {
+		seen[color.RGBA64Model.Convert(e.automaton.Color(e, 0, c))] = true
+	}
+	if len(seen) != 6 {
+		t.Errorf("distinct colors = %d; want 6", len(seen))
+	}
+}
+
+func TestCheckCyclic(t *testing.T) {
+	for _, cc := range [...]Cyclic{
+		{States: 1, Threshold: 1, Range: 1, Neighborhood: "moore"},
+		{States: 3, Threshold: 9, Range: 1, Neighborhood: "moore"},
+		{States: 3, Threshold: 5, Range: 1, Neighborhood: "vonneumann"},
+		{States: 3, Threshold: 1, Range: 0, Neighborhood: "moore"},
+		{States: 3, Threshold: 1, Range: 1, Neighborhood: "hex"},
+	} {
+		c := NewConfig()
+		c.Cyclic = cc
+		if err := c.checkCyclic(); err == nil {
+			t.Errorf("checkCyclic(%+v) succeeded", cc)
+		}
+	}
+	if err := NewConfig().checkCyclic(); err != nil {
+		t.Errorf("checkCyclic of the defaults: %v", err)
+	}
+}
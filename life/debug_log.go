@@ -8,6 +8,10 @@ import (
 	"strings"
 )
 
+// Logs on every tick are only built in debug builds, so that their
+// arguments are not allocated otherwise.
+const debug = true
+
 type DebugLogger struct {
 	domains map[string]struct{}
 }
@@ -31,9 +35,11 @@ func (lg *DebugLogger) log(domain, format string, v ...interface{}) {
 func init() {
 	logger = &DebugLogger{
 		domains: map[string]struct{}{
-			"config":   {},
-			"schedule": {},
-			"seed":     {},
+			"config":     {},
+			"schedule":   {},
+			"seed":       {},
+			"stagnation": {},
+			"state":      {},
 		},
 	}
 }
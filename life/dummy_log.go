@@ -2,6 +2,8 @@
 
 package life
 
+const debug = false
+
 type DummyLogger struct {
 }
 
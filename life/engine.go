@@ -0,0 +1,196 @@
+package life
+
+import (
+	"image/color"
+	"math/rand"
+	"sync"
+)
+
+// An engine computes the next generation of an Env into its buffer, along
+// with the dead zones of the next generation. Engines keeping their own
+// representation of the world are told about changes made outside of step.
+type engine interface {
+	step(e *Env)
+	load(cells Cells)
+	set(idx, c int)
+}
+
+// An advancer is an engine able to compute generations far ahead at once.
+type advancer interface {
+	advance(e *Env, n int)
+}
+
+// A band is a range of whole rows whose next generation is computed by one
+// worker. The random source of a band is seeded on every row from a seed
+// drawn once per tick, so that the results depend neither on the scheduling
+// of workers nor on their number.
+type band struct {
+	index     int
+	first     int
+	last      int
+	seed      uint64
+	source    rowSource
+	rand      *rand.Rand
+	deadZones Cells
+	parents   []color.RGBA
+	// Neighbors computed without a neighbor table.
+	scratch Neighbors
+}
+
+func newBands(width, height, n int) []*band {
+	if n > height {
+		n = height
+	}
+	bs := make([]*band, n)
+
+	for i := range bs {
+		b := &band{
+			index: i,
+			first: height * i / n,
+			last:  height * (i + 1) / n,
+		}
+		b.rand = rand.New(&b.source)
+		bs[i] = b
+	}
+
+	return bs
+}
+
+func (b *band) cells(width int) (int, int) {
+	return b.first * width, b.last * width
+}
+
+// seedRow seeds the random source of the band for row y.
+func (b *band) seedRow(y int) {
+	b.source.state = mix64(b.seed + uint64(y)*0x9e3779b97f4a7c15)
+}
+
+// rowSource is a splitmix64 generator, which unlike the sources of
+// math/rand is cheap enough to be seeded on every row.
+type rowSource struct {
+	state uint64
+}
+
+func mix64(z uint64) uint64 {
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+func (s *rowSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	return mix64(s.state)
+}
+
+func (s *rowSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *rowSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// parallel calls f for every band concurrently, their random sources being
+// seeded for the tick.
+func (e *Env) parallel(f func(b *band)) {
+	seed := e.rand.Uint64()
+	for _, b := range e.bands {
+		b.seed = seed
+	}
+	e.concurrently(f)
+}
+
+// findDeadZones calls f for every band concurrently to find the dead zones
+// of the next generation, then gathers them in order.
+func (e *Env) findDeadZones(f func(b *band)) {
+	for _, b := range e.bands {
+		b.deadZones = b.deadZones[:0]
+	}
+	e.concurrently(f)
+
+	e.deadZones = e.deadZones[:0]
+	for _, b := range e.bands {
+		e.deadZones = append(e.deadZones, b.deadZones...)
+	}
+}
+
+func (e *Env) concurrently(f func(b *band)) {
+	if len(e.bands) == 1 {
+		f(e.bands[0])
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, b := range e.bands {
+		wg.Add(1)
+		go func(b *band) {
+			defer wg.Done()
+			f(b)
+		}(b)
+	}
+	wg.Wait()
+}
+
+// tableEngine looks up the neighbors of every cell in the neighbor table of
+// the Env, or computes them if the table is too large. It supports every
+// rule, topology and color mode. The functions run on the bands are made
+// once, so that steps do not allocate.
+type tableEngine struct {
+	stepBand  func(b *band)
+	zonesBand func(b *band)
+}
+
+func newTableEngine(e *Env) *tableEngine {
+	return &tableEngine{
+		stepBand:  func(b *band) { stepTableBand(e, b) },
+		zonesBand: func(b *band) { findTableDeadZones(e, b) },
+	}
+}
+
+func (t *tableEngine) step(e *Env) {
+	e.parallel(t.stepBand)
+	e.findDeadZones(t.zonesBand)
+}
+
+func stepTableBand(e *Env, b *band) {
+	for y := b.first; y < b.last; y++ {
+		b.seedRow(y)
+
+		for i := y * e.width; i < (y+1)*e.width; i++ {
+			ns := e.bandNeighbors(b, i)
+			n, cs := getContext(e.cells, ns)
+			e.buffer[i] = e.rule.apply(e.cells[i], n, cs,
+				e.inheritance.inherit, b.rand)
+			if e.colors != nil {
+				e.colorBuffer[i] = e.trueColor(i, e.buffer[i], ns, b)
+			}
+			if e.ages != nil {
+				e.ageBuffer[i] = e.age(i)
+			}
+		}
+	}
+}
+
+// findTableDeadZones finds the dead cells of the next generation without
+// live neighbors, once every band has been stepped.
+func findTableDeadZones(e *Env, b *band) {
+	first, last := b.cells(e.width)
+cells:
+	for i := first; i < last; i++ {
+		if e.buffer[i] != cellDead {
+			continue
+		}
+		for _, j := range e.bandNeighbors(b, i) {
+			if j >= 0 && isLive(e.buffer[j]) {
+				continue cells
+			}
+		}
+		b.deadZones = append(b.deadZones, i)
+	}
+}
+
+func (*tableEngine) load(cells Cells) {
+}
+
+func (*tableEngine) set(idx, c int) {
+}
@@ -0,0 +1,104 @@
+package life
+
+import (
+	"testing"
+)
+
+// workers configures an engine, an inheritance and a number of workers.
+func workers(engine, inheritance string, n int) func(c *Config) {
+	return func(c *Config) {
+		c.Engine = engine
+		c.Inheritance = inheritance
+		c.Workers = n
+	}
+}
+
+// testWorkers compares the generations computed by one and n workers, with
+// seeding, which draws from the random source of the Env in between.
+func testWorkers(t *testing.T, engine, inheritance string, n int,
+	configure ...func(c *Config)) {
+	run := func(n int) *Env {
+		e := newTestEnv(t, 70, 37, append(configure,
+			workers(engine, inheritance, n))...)
+		e.seedCooldownTicks = 0
+		for g := 0; g < 50; g++ {
+			e.tick()
+		}
+		return e
+	}
+
+	e, p := run(1), run(n)
+	for i, c := range p.cells {
+		if c != e.cells[i] {
+			t.Fatalf("%s, %s with %d workers: cell[%d] = %d; want %d",
+				engine, inheritance, n, i, c, e.cells[i])
+		}
+	}
+	for i, c := range p.colors {
+		if c != e.colors[i] {
+			t.Fatalf("%s with %d workers: color[%d] = %v; want %v",
+				engine, n, i, c, e.colors[i])
+		}
+	}
+}
+
+func TestNewBands(t *testing.T) {
+	bs := newBands(10, 7, 3)
+	want := [...][2]int{{0, 2}, {2, 4}, {4, 7}}
+
+	for i, b := range bs {
+		if b.first != want[i][0] || b.last != want[i][1] {
+			t.Errorf("band[%d] = [%d, %d); want [%d, %d)",
+				i, b.first, b.last, want[i][0], want[i][1])
+		}
+	}
+
+	if n := len(newBands(10, 2, 4)); n != 2 {
+		t.Errorf("len(bands) = %d; want 2", n)
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	for _, engine := range engineNames {
+		for _, inheritance := range [...]string{"majority", "random"} {
+			testWorkers(t, engine, inheritance, 4)
+		}
+	}
+	testWorkers(t, "table", "random", 3, func(c *Config) {
+		c.Color.Mode = "truecolor"
+	})
+}
+
+func TestWorkersDeterministic(t *testing.T) {
+	for _, engine := range engineNames {
+		e := newTestEnv(t, 70, 37, workers(engine, "random", 4))
+		e.seedCooldownTicks = 0
+		for g := 0; g < 50; g++ {
+			e.tick()
+		}
+
+		p := newTestEnv(t, 70, 37, workers(engine, "random", 4))
+		p.seedCooldownTicks = 0
+		for g := 0; g < 50; g++ {
+			p.tick()
+		}
+
+		for i, c := range p.cells {
+			if c != e.cells[i] {
+				t.Fatalf("%s: cell[%d] = %d; want %d",
+					engine, i, c, e.cells[i])
+			}
+		}
+	}
+}
+
+func BenchmarkTickTable256Workers(b *testing.B) {
+	e := newTestEnv(b, 256, 256, withRule("B3/S23"))
+	e.bands = newBands(e.width, e.height, 4)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.tick()
+	}
+}
@@ -0,0 +1,107 @@
+package life
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"lifelight/pattern"
+)
+
+// ImageRenderer draws the pixels of the matrix onto an image, as squares of
+// Scale pixels.
+type ImageRenderer struct {
+	Image *image.RGBA
+	Scale int
+}
+
+func NewImageRenderer(c *Config, scale int) *ImageRenderer {
+	return &ImageRenderer{
+		Image: image.NewRGBA(image.Rect(0, 0, c.Hardware.MatrixWidth*scale,
+			c.Hardware.MatrixHeight*scale)),
+		Scale: scale,
+	}
+}
+
+func (r *ImageRenderer) Set(x, y int, c color.Color) {
+	s := r.Scale
+	draw.Draw(r.Image, image.Rect(x*s, y*s, (x+1)*s, (y+1)*s),
+		&image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+func (r *ImageRenderer) Render() error {
+	return nil
+}
+
+// Patterns are exported under this name, which tells their cells from
+// those of Golly's patterns.
+const exportName = "lifelight"
+
+// Pattern returns the current generation as a pattern, holding the states
+// of the cells along with their colors.
+func (e *Env) Pattern() *pattern.Pattern {
+	p := &pattern.Pattern{
+		Name:   exportName,
+		Rule:   e.automaton.String(),
+		Width:  e.width,
+		Height: e.height,
+		Cells:  make([]int, e.size),
+	}
+	copy(p.Cells, e.cells)
+
+	return p
+}
+
+// Image returns the current generation as drawn on the matrix, with every
+// pixel scaled up to a square of scale pixels.
+func (e *Env) Image(scale int) *image.RGBA {
+	r := NewImageRenderer(e.config, scale)
+	e.Draw(r)
+
+	return r.Image
+}
+
+func writeFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Export saves the current generation to Export.Dir as an RLE pattern and a
+// PNG image, both named after the current time. It returns the paths of the
+// files.
+func (e *Env) Export() ([]string, error) {
+	c := e.config.Export
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	base := filepath.Join(c.Dir,
+		"lifelight-"+time.Now().Format("20060102-150405"))
+	rle, img := base+".rle", base+".png"
+
+	p := e.Pattern()
+	p.Comments = []string{time.Now().Format(time.RFC1123)}
+	if err := writeFile(rle, func(f *os.File) error {
+		return pattern.WriteRLE(f, p)
+	}); err != nil {
+		return nil, err
+	}
+	if err := writeFile(img, func(f *os.File) error {
+		return png.Encode(f, e.Image(c.Scale))
+	}); err != nil {
+		return []string{rle}, err
+	}
+
+	return []string{rle, img}, nil
+}
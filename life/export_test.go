@@ -0,0 +1,77 @@
+package life
+
+import (
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestPatternRoundTrip(t *testing.T) {
+	e := newTestEnv(t, 16, 8, withRule("B2/S/C3"))
+	for g := 0; g < 3; g++ {
+		e.tick()
+	}
+	p := e.Pattern()
+	if p.Rule != "B2/S/C3" {
+		t.Errorf("rule = %s; want B2/S/C3", p.Rule)
+	}
+
+	l := newTestEnv(t, 16, 8, withRule("B2/S/C3"))
+	if err := l.config.checkPattern(p); err != nil {
+		t.Fatal(err)
+	}
+	l.LoadPattern(p)
+	for i, c := range l.cells {
+		if c != e.cells[i] {
+			t.Errorf("cell[%d] = %d; want %d", i, c, e.cells[i])
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	e := newTestEnv(t, 4, 3, withRule("B3/S23"))
+	copy(e.cells, Cells{
+		0, 1, 0, 0,
+		0, 0, 2, 0,
+		0, 0, 0, 0,
+	})
+
+	img := e.Image(3)
+	if b := img.Bounds(); b.Dx() != 12 || b.Dy() != 9 {
+		t.Fatalf("size = %dx%d; want 12x9", b.Dx(), b.Dy())
+	}
+
+	want := color.RGBAModel.Convert(colorScheme[cellLive2])
+	for _, p := range [...][2]int{{6, 3}, {8, 5}} {
+		if c := img.At(p[0], p[1]); c != want {
+			t.Errorf("pixel (%d, %d) = %v; want %v", p[0], p[1], c, want)
+		}
+	}
+	if c := img.At(9, 3); c != color.RGBAModel.Convert(color.Black) {
+		t.Errorf("pixel (9, 3) = %v; want black", c)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lifelight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	e := newTestEnv(t, 8, 8, withRule("B3/S23"))
+	e.config.Export.Dir = dir
+	paths, err := e.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			t.Error(err)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("paths = %v; want 2 files", paths)
+	}
+}
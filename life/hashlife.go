@@ -0,0 +1,455 @@
+package life
+
+import (
+	"math/rand"
+)
+
+// A node is a canonical square of 2^level cells. Nodes with the same
+// quadrants are shared, so that the successors of every distinct square are
+// only computed once.
+type node struct {
+	nw         *node
+	ne         *node
+	sw         *node
+	se         *node
+	level      int
+	population int
+}
+
+type quad [4]*node
+
+type memoKey struct {
+	n *node
+	j int
+}
+
+// Caches are dropped after a step once their nodes, taking about
+// hashlifeNodeSize bytes each along with their memo entries, exceed
+// hashlifeMemory. Soups of 512x512 cells then drop them every 16 ticks or so,
+// though a single step of such soups takes over 100 MB while it runs.
+const (
+	hashlifeMemory   = 64 << 20
+	hashlifeNodeSize = 200
+	hashlifeMaxNodes = hashlifeMemory / hashlifeNodeSize
+)
+
+// hashlife runs two-state rules on the Moore neighborhood of radius 1 in an
+// unbounded world, advancing by powers of two generations with memoized
+// quadtrees. The world is centered on the origin.
+type hashlife struct {
+	birth    []bool
+	survival []bool
+	nodes    map[quad]*node
+	memo     map[memoKey]*node
+	leaves   [2]*node
+	empty    []*node
+	root     *node
+}
+
+func newHashlife(r rule) *hashlife {
+	h := &hashlife{
+		birth:    r.birth,
+		survival: r.survival,
+		nodes:    make(map[quad]*node),
+		memo:     make(map[memoKey]*node),
+		leaves:   [2]*node{{level: 0}, {level: 0, population: 1}},
+	}
+	h.root = h.emptyNode(3)
+
+	return h
+}
+
+func (h *hashlife) join(nw, ne, sw, se *node) *node {
+	q := quad{nw, ne, sw, se}
+	if n, ok := h.nodes[q]; ok {
+		return n
+	}
+
+	n := &node{
+		nw:         nw,
+		ne:         ne,
+		sw:         sw,
+		se:         se,
+		level:      nw.level + 1,
+		population: nw.population + ne.population + sw.population + se.population,
+	}
+	h.nodes[q] = n
+
+	return n
+}
+
+func (h *hashlife) emptyNode(level int) *node {
+	if len(h.empty) == 0 {
+		h.empty = append(h.empty, h.leaves[0])
+	}
+	for len(h.empty) <= level {
+		e := h.empty[len(h.empty)-1]
+		h.empty = append(h.empty, h.join(e, e, e, e))
+	}
+	return h.empty[level]
+}
+
+// expand returns a node twice the size of n, with n at its center.
+func (h *hashlife) expand(n *node) *node {
+	e := h.emptyNode(n.level - 1)
+
+	return h.join(
+		h.join(e, e, e, n.nw),
+		h.join(e, e, n.ne, e),
+		h.join(e, n.sw, e, e),
+		h.join(n.se, e, e, e))
+}
+
+// centered reports whether all live cells of n are within its central
+// square of half its size.
+func centered(n *node) bool {
+	return n.nw.se.se.population+n.ne.sw.sw.population+
+		n.sw.ne.ne.population+n.se.nw.nw.population == n.population
+}
+
+// cell returns the state of the cell at x, y relative to the top left of n.
+func cell(n *node, x, y int) int {
+	for n.level > 0 {
+		half := 1 << uint(n.level-1)
+		switch {
+		case x < half && y < half:
+			n = n.nw
+		case y < half:
+			n, x = n.ne, x-half
+		case x < half:
+			n, y = n.sw, y-half
+		default:
+			n, x, y = n.se, x-half, y-half
+		}
+	}
+	return n.population
+}
+
+// life4x4 returns the center of a node of level 2 after one generation.
+func (h *hashlife) life4x4(n *node) *node {
+	var cs [4]*node
+
+	for i := range cs {
+		x, y := 1+i%2, 1+i/2
+		c := 0
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					c += cell(n, x+dx, y+dy)
+				}
+			}
+		}
+		alive := h.birth[c]
+		if cell(n, x, y) == 1 {
+			alive = h.survival[c]
+		}
+		cs[i] = h.leaves[0]
+		if alive {
+			cs[i] = h.leaves[1]
+		}
+	}
+
+	return h.join(cs[0], cs[1], cs[2], cs[3])
+}
+
+// successor returns the center of n after 2^j generations, with j at most
+// the level of n minus 2.
+func (h *hashlife) successor(n *node, j int) *node {
+	if n.population == 0 {
+		return n.nw
+	}
+	if n.level == 2 {
+		return h.life4x4(n)
+	}
+
+	key := memoKey{n, j}
+	if s, ok := h.memo[key]; ok {
+		return s
+	}
+
+	j1 := j
+	if j1 > n.level-3 {
+		j1 = n.level - 3
+	}
+
+	c := [9]*node{
+		h.successor(n.nw, j1),
+		h.successor(h.join(n.nw.ne, n.ne.nw, n.nw.se, n.ne.sw), j1),
+		h.successor(n.ne, j1),
+		h.successor(h.join(n.nw.sw, n.nw.se, n.sw.nw, n.sw.ne), j1),
+		h.successor(h.join(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw), j1),
+		h.successor(h.join(n.ne.sw, n.ne.se, n.se.nw, n.se.ne), j1),
+		h.successor(n.sw, j1),
+		h.successor(h.join(n.sw.ne, n.se.nw, n.sw.se, n.se.sw), j1),
+		h.successor(n.se, j1),
+	}
+
+	var s *node
+	if j < n.level-2 {
+		s = h.join(
+			h.join(c[0].se, c[1].sw, c[3].ne, c[4].nw),
+			h.join(c[1].se, c[2].sw, c[4].ne, c[5].nw),
+			h.join(c[3].se, c[4].sw, c[6].ne, c[7].nw),
+			h.join(c[4].se, c[5].sw, c[7].ne, c[8].nw))
+	} else {
+		s = h.join(
+			h.successor(h.join(c[0], c[1], c[3], c[4]), j1),
+			h.successor(h.join(c[1], c[2], c[4], c[5]), j1),
+			h.successor(h.join(c[3], c[4], c[6], c[7]), j1),
+			h.successor(h.join(c[4], c[5], c[7], c[8]), j1))
+	}
+	h.memo[key] = s
+
+	return s
+}
+
+// step advances the world by 2^j generations.
+func (h *hashlife) step(j int) {
+	for h.root.level < j+3 || !centered(h.root) {
+		h.root = h.expand(h.root)
+	}
+	h.root = h.successor(h.root, j)
+
+	if len(h.nodes) > hashlifeMaxNodes {
+		h.collect()
+	}
+}
+
+func (h *hashlife) advance(n int) {
+	for j := 0; n > 0; j, n = j+1, n>>1 {
+		if n&1 == 1 {
+			h.step(j)
+		}
+	}
+}
+
+// collect drops the caches, keeping only the nodes of the world and the
+// successors among them, e.g. of still lifes.
+func (h *hashlife) collect() {
+	memo := h.memo
+	h.nodes = make(map[quad]*node)
+	h.memo = make(map[memoKey]*node)
+	h.empty = nil
+
+	nodes := make(map[*node]*node)
+
+	var rebuild func(n *node) *node
+	rebuild = func(n *node) *node {
+		if n.level == 0 {
+			return n
+		}
+		if r, ok := nodes[n]; ok {
+			return r
+		}
+		r := h.join(rebuild(n.nw), rebuild(n.ne), rebuild(n.sw), rebuild(n.se))
+		nodes[n] = r
+		return r
+	}
+
+	h.root = rebuild(h.root)
+
+	for k, s := range memo {
+		n, ok := nodes[k.n]
+		r, rok := nodes[s]
+		if ok && rok {
+			h.memo[memoKey{n, k.j}] = r
+		}
+	}
+}
+
+// origin returns the coordinates of the top left of the world.
+func (h *hashlife) origin() int {
+	return -(1 << uint(h.root.level-1))
+}
+
+func (h *hashlife) get(x, y int) int {
+	o := h.origin()
+	x, y = x-o, y-o
+	if x < 0 || y < 0 || x >= -2*o || y >= -2*o {
+		return 0
+	}
+	return cell(h.root, x, y)
+}
+
+func (h *hashlife) setCell(n *node, x, y, v int) *node {
+	if n.level == 0 {
+		return h.leaves[v]
+	}
+
+	half := 1 << uint(n.level-1)
+	switch {
+	case x < half && y < half:
+		return h.join(h.setCell(n.nw, x, y, v), n.ne, n.sw, n.se)
+	case y < half:
+		return h.join(n.nw, h.setCell(n.ne, x-half, y, v), n.sw, n.se)
+	case x < half:
+		return h.join(n.nw, n.ne, h.setCell(n.sw, x, y-half, v), n.se)
+	}
+	return h.join(n.nw, n.ne, n.sw, h.setCell(n.se, x-half, y-half, v))
+}
+
+func (h *hashlife) set(x, y, v int) {
+	for {
+		o := h.origin()
+		if x >= o && y >= o && x < -o && y < -o {
+			h.root = h.setCell(h.root, x-o, y-o, v)
+			return
+		}
+		h.root = h.expand(h.root)
+	}
+}
+
+// fill calls f with the coordinates of the live cells of n within the
+// rectangle of the given size, relative to the top left of n at x, y.
+func fill(n *node, x, y, width, height int, f func(int, int)) {
+	size := 1 << uint(n.level)
+	if n.population == 0 || x >= width || y >= height ||
+		x+size <= 0 || y+size <= 0 {
+		return
+	}
+	if n.level == 0 {
+		f(x, y)
+		return
+	}
+
+	half := size / 2
+	fill(n.nw, x, y, width, height, f)
+	fill(n.ne, x+half, y, width, height, f)
+	fill(n.sw, x, y+half, width, height, f)
+	fill(n.se, x+half, y+half, width, height, f)
+}
+
+// hashEngine runs an Env on hashlife. The Env shows the part of the
+// unbounded world around the origin; cells leaving it carry on beyond the
+// edges of the matrix. The colors of live cells are only known within the
+// Env.
+type hashEngine struct {
+	life   *hashlife
+	width  int
+	height int
+	alive  []bool
+}
+
+func newHashEngine(e *Env) *hashEngine {
+	return &hashEngine{
+		life:   newHashlife(e.rule),
+		width:  e.width,
+		height: e.height,
+		alive:  make([]bool, e.size),
+	}
+}
+
+// world returns the coordinates of cell idx in the world.
+func (h *hashEngine) world(idx int) (int, int) {
+	x, y := getCoords(idx, h.width)
+	return x - h.width/2, y - h.height/2
+}
+
+// inherit picks the color of a cell born at x, y from its live neighbors in
+// the current generation.
+func (h *hashEngine) inherit(e *Env, x, y int, r *rand.Rand) int {
+	var cs [LiveCellN]int
+
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if nx < 0 || nx >= h.width || ny < 0 || ny >= h.height {
+				continue
+			}
+			if c := e.cells[getIdx(nx, ny, h.width)]; isLive(c) {
+				cs[c-1]++
+			}
+		}
+	}
+
+	return e.inheritance.inherit(cs, r)
+}
+
+// unpack writes the world to the buffer of the Env, along with its dead
+// zones.
+func (h *hashEngine) unpack(e *Env) {
+	for i := range h.alive {
+		h.alive[i] = false
+	}
+	o := h.life.origin()
+	fill(h.life.root, o+h.width/2, o+h.height/2, h.width, h.height,
+		func(x, y int) {
+			h.alive[getIdx(x, y, h.width)] = true
+		})
+
+	e.parallel(func(b *band) {
+		for y := b.first; y < b.last; y++ {
+			b.seedRow(y)
+
+			for x := 0; x < h.width; x++ {
+				i := getIdx(x, y, h.width)
+				switch {
+				case !h.alive[i]:
+					e.buffer[i] = cellDead
+				case isLive(e.cells[i]):
+					e.buffer[i] = e.cells[i]
+				default:
+					e.buffer[i] = h.inherit(e, x, y, b.rand)
+				}
+				if e.ages != nil {
+					e.ageBuffer[i] = e.age(i)
+				}
+			}
+		}
+	})
+
+	e.findDeadZones(func(b *band) {
+		for y := b.first; y < b.last; y++ {
+			for x := 0; x < h.width; x++ {
+				if h.dead(x, y) {
+					b.deadZones = append(b.deadZones, getIdx(x, y, h.width))
+				}
+			}
+		}
+	})
+}
+
+// dead reports whether x, y and its neighbors within the Env are all dead
+// in the next generation.
+func (h *hashEngine) dead(x, y int) bool {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if nx < 0 || nx >= h.width || ny < 0 || ny >= h.height {
+				continue
+			}
+			if h.alive[getIdx(nx, ny, h.width)] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (h *hashEngine) step(e *Env) {
+	h.life.advance(1)
+	h.unpack(e)
+}
+
+func (h *hashEngine) advance(e *Env, n int) {
+	h.life.advance(n)
+	h.unpack(e)
+}
+
+func (h *hashEngine) load(cells Cells) {
+	h.life.root = h.life.emptyNode(3)
+	for i, c := range cells {
+		if isLive(c) {
+			h.set(i, c)
+		}
+	}
+}
+
+func (h *hashEngine) set(idx, c int) {
+	x, y := h.world(idx)
+	v := 0
+	if isLive(c) {
+		v = 1
+	}
+	h.life.set(x, y, v)
+}
@@ -0,0 +1,200 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type point struct {
+	x int
+	y int
+}
+
+// naiveUnbounded computes the next generation of an unbounded world.
+func naiveUnbounded(r rule, live map[point]bool) map[point]bool {
+	counts := make(map[point]int)
+	for p := range live {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					counts[point{p.x + dx, p.y + dy}]++
+				}
+			}
+		}
+	}
+
+	next := make(map[point]bool)
+	for p, n := range counts {
+		if live[p] && r.survival[n] || !live[p] && r.birth[n] {
+			next[p] = true
+		}
+	}
+	return next
+}
+
+func TestHashlifeAdvance(t *testing.T) {
+	for _, rs := range [...]string{"B3/S23", "B36/S23", "B3678/S34678"} {
+		r, _ := parseRule(rs)
+
+		for _, n := range [...]int{1, 2, 7, 64, 100} {
+			h := newHashlife(r)
+			live := make(map[point]bool)
+			for i := 0; i < 200; i++ {
+				p := point{rand.Intn(20) - 10, rand.Intn(20) - 10}
+				live[p] = true
+				h.set(p.x, p.y, 1)
+			}
+
+			h.advance(n)
+			for g := 0; g < n; g++ {
+				live = naiveUnbounded(r, live)
+			}
+
+			if h.root.population != len(live) {
+				t.Fatalf("%s after %d generations: population = %d; want %d",
+					rs, n, h.root.population, len(live))
+			}
+			for p := range live {
+				if h.get(p.x, p.y) != 1 {
+					t.Fatalf("%s after %d generations: cell (%d, %d) is dead",
+						rs, n, p.x, p.y)
+				}
+			}
+		}
+	}
+}
+
+func TestHashlifeCollect(t *testing.T) {
+	h, k := newHashlife(conway), newHashlife(conway)
+	for i := 0; i < 100; i++ {
+		x, y := rand.Intn(16), rand.Intn(16)
+		h.set(x, y, 1)
+		k.set(x, y, 1)
+	}
+	h.advance(10)
+	k.advance(10)
+	want := h.root.population
+
+	h.collect()
+	if h.root.population != want {
+		t.Errorf("population = %d; want %d", h.root.population, want)
+	}
+	// Kept successors only refer to the rebuilt nodes.
+	for m, s := range h.memo {
+		if h.nodes[quad{m.n.nw, m.n.ne, m.n.sw, m.n.se}] != m.n ||
+			h.nodes[quad{s.nw, s.ne, s.sw, s.se}] != s {
+			t.Fatalf("memo entry of level %d refers to dropped nodes",
+				m.n.level)
+		}
+	}
+
+	h.advance(10)
+	k.advance(10)
+	for x := -32; x < 32; x++ {
+		for y := -32; y < 32; y++ {
+			if h.get(x, y) != k.get(x, y) {
+				t.Fatalf("cell (%d, %d) differs after collecting", x, y)
+			}
+		}
+	}
+}
+
+func TestHashEngineGlider(t *testing.T) {
+	e := newTestEnv(t, gliderWidth, gliderHeight, withEngine("hashlife"))
+	copy(e.cells, glider0)
+	e.engine.load(e.cells)
+
+	e.tick()
+
+	for i, c := range e.cells {
+		if c != glider1[i] {
+			t.Errorf("cell = %d; want %d", c, glider1[i])
+		}
+	}
+}
+
+func TestHashEngineAdvance(t *testing.T) {
+	e := newTestEnv(t, 9, 9, withEngine("hashlife"), withBlank)
+	for i, c := range glider0 {
+		x, y := getCoords(i, gliderWidth)
+		e.cells[getIdx(x+2, y+2, e.width)] = c
+	}
+	e.engine.load(e.cells)
+
+	// The glider has left the matrix, but lives on beyond its edges.
+	e.Advance(1 << 12)
+	live := 0
+	for _, c := range e.cells {
+		if isLive(c) {
+			live++
+		}
+	}
+	if live != 0 {
+		t.Errorf("live cells = %d; want 0", live)
+	}
+	if p := e.engine.(*hashEngine).life.root.population; p != 5 {
+		t.Errorf("population = %d; want 5", p)
+	}
+}
+
+func TestHashEngineTable(t *testing.T) {
+	for _, r := range [...]string{"B3/S23", "B36/S23", "B2/S"} {
+		e := newTestEnv(t, 70, 20, withRule(r), withEngine("hashlife"))
+		table := newTestEnv(t, 70, 20, withRule(r), withTopology("bounded"))
+		copy(e.cells, table.cells)
+		e.engine.load(e.cells)
+
+		// The worlds only differ once cells leave the matrix.
+		e.tick()
+		table.tick()
+
+		for i, c := range e.cells {
+			if c != table.cells[i] {
+				t.Fatalf("%s: cell[%d] = %d; want %d", r, i, c,
+					table.cells[i])
+			}
+		}
+		if len(e.deadZones) != len(table.deadZones) {
+			t.Fatalf("%s: deadzones = %d; want %d", r, len(e.deadZones),
+				len(table.deadZones))
+		}
+	}
+}
+
+func BenchmarkTickHashlife256(b *testing.B) {
+	e := newTestEnv(b, 256, 256, withEngine("hashlife"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.tick()
+	}
+}
+
+func TestCheckEngine(t *testing.T) {
+	for _, rs := range [...]string{"B3/S23", "B36/S23", "B3678/S34678"} {
+		c := NewConfig()
+		c.Engine = "hashlife"
+		c.Rule = rs
+		c.rule, _ = parseRule(rs)
+		if err := c.checkEngine(); err != nil {
+			t.Errorf("checkEngine(%s): %v", rs, err)
+		}
+	}
+	for _, rs := range [...]string{"B0/S8", "B013/S0123", "B3/S23/C3"} {
+		c := NewConfig()
+		c.Engine = "hashlife"
+		c.Rule = rs
+		c.rule, _ = parseRule(rs)
+		if err := c.checkEngine(); err == nil {
+			t.Errorf("checkEngine(%s) succeeded", rs)
+		}
+	}
+
+	c := NewConfig()
+	c.Engine = "hashlife"
+	c.Topology = "bounded"
+	if err := c.checkEngine(); err == nil {
+		t.Error("checkEngine(bounded) succeeded")
+	}
+}
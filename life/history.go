@@ -0,0 +1,222 @@
+package life
+
+// A change is the state of a cell before and after a generation.
+type change struct {
+	idx, from, to int32
+}
+
+// Bytes taken by a change, and by every generation besides its changes.
+const (
+	changeSize     = 12
+	generationSize = 24
+)
+
+// history holds the latest generations as the changes from one to the
+// next, in a ring buffer bounded by both a number of generations and their
+// size in bytes. Generations that have been rewound are kept until the
+// world is reseeded, so that they can be replayed.
+type history struct {
+	frames [][]change
+	start  int
+	n      int
+	pos    int
+	bytes  int
+	memory int
+	last   Cells
+	spare  []change
+	// The first generation loaded is where the history starts from.
+	started bool
+	// The automaton is out of sync with the cells while generations are
+	// rewound or replayed.
+	dirty bool
+}
+
+func newHistory(size, generations, memory int) *history {
+	return &history{
+		frames: make([][]change, generations),
+		memory: memory,
+		last:   make(Cells, size),
+	}
+}
+
+func (h *history) frame(i int) []change {
+	return h.frames[(h.start+i)%len(h.frames)]
+}
+
+// drop drops the oldest generation, keeping its frame to be reused.
+func (h *history) drop() {
+	h.bytes -= generationSize + cap(h.frames[h.start])*changeSize
+	h.frames[h.start] = h.frames[h.start][:0]
+	h.start = (h.start + 1) % len(h.frames)
+	h.n--
+	h.pos--
+}
+
+// push adds the changes from the previous generation to cells, dropping
+// the generations that have been rewound and the oldest ones not fitting.
+// Frames are reused so that pushing does not allocate once the history is
+// full. Frames dropped for lack of memory are freed, but for one spare.
+func (h *history) push(cells Cells) {
+	for h.n > h.pos {
+		h.n--
+		i := (h.start + h.n) % len(h.frames)
+		h.bytes -= generationSize + cap(h.frames[i])*changeSize
+		h.frames[i] = h.frames[i][:0]
+	}
+
+	if h.n == len(h.frames) {
+		h.drop()
+	}
+	i := (h.start + h.n) % len(h.frames)
+	f := h.frames[i]
+	if f == nil {
+		f, h.spare = h.spare, nil
+	}
+	for j, c := range cells {
+		if c != h.last[j] {
+			f = append(f, change{int32(j), int32(h.last[j]), int32(c)})
+			h.last[j] = c
+		}
+	}
+	h.frames[i] = f
+	h.n++
+	h.pos++
+	h.bytes += generationSize + cap(f)*changeSize
+
+	for h.bytes > h.memory && h.n > 1 {
+		start := h.start
+		h.drop()
+		h.spare, h.frames[start] = h.frames[start], nil
+	}
+}
+
+// back undoes the latest generation of cells, if there is one.
+func (h *history) back(e *Env) bool {
+	if h.pos == 0 {
+		return false
+	}
+	h.pos--
+	for _, c := range h.frame(h.pos) {
+		e.restoreCell(int(c.idx), int(c.from))
+	}
+	h.dirty = true
+	return true
+}
+
+// forward replays the next rewound generation, if there is one.
+func (h *history) forward(e *Env) bool {
+	if h.pos == h.n {
+		return false
+	}
+	for _, c := range h.frame(h.pos) {
+		e.restoreCell(int(c.idx), int(c.to))
+	}
+	h.pos++
+	h.dirty = true
+	return true
+}
+
+// sync loads the cells into the automaton once they are no longer rewound.
+func (h *history) sync(e *Env) {
+	if h.dirty {
+		e.automaton.Load(e)
+		h.dirty = false
+	}
+}
+
+// restoreCell sets cell idx of the current generation to state c, reset to
+// the color of its state.
+func (e *Env) restoreCell(idx, c int) {
+	e.cells[idx] = c
+	h := e.history
+	h.last[idx] = c
+	if e.colors != nil {
+		e.colors[idx] = schemeColor(c)
+	}
+	if e.ages != nil {
+		e.ages[idx] = 0
+	}
+}
+
+// record adds the current generation to the history, if it is kept.
+func (e *Env) record() {
+	if e.history != nil {
+		e.history.push(e.cells)
+	}
+}
+
+// loaded adds a generation loaded into the automaton to the history, as a
+// jump from the previous one.
+func (h *history) loaded(cells Cells) {
+	if h.started {
+		h.push(cells)
+	} else {
+		copy(h.last, cells)
+		h.started = true
+	}
+	h.dirty = false
+}
+
+// Rewind steps back up to n generations, returning the number of
+// generations stepped back. Rewound generations are replayed by the
+// following ticks, unless the world is reseeded.
+func (e *Env) Rewind(n int) int {
+	if e.history == nil {
+		return 0
+	}
+
+	i := 0
+	for ; i < n && e.history.back(e); i++ {
+	}
+	if i > 0 {
+		e.event("rewind %d", i)
+		if e.recorder != nil {
+			e.recorder.frame(e)
+		}
+	}
+	return i
+}
+
+// StepBack steps back one generation, returning false if there is none
+// left.
+func (e *Env) StepBack() bool {
+	return e.Rewind(1) == 1
+}
+
+// Forward replays up to n rewound generations, returning the number of
+// generations replayed.
+func (e *Env) Forward(n int) int {
+	if e.history == nil {
+		return 0
+	}
+
+	i := 0
+	for ; i < n && e.history.forward(e); i++ {
+	}
+	if i > 0 && e.recorder != nil {
+		e.recorder.frame(e)
+	}
+	return i
+}
+
+// Behind returns the number of generations rewound.
+func (e *Env) Behind() int {
+	if e.history == nil {
+		return 0
+	}
+	return e.history.n - e.history.pos
+}
+
+// History returns the number of generations that can be rewound.
+func (e *Env) History() int {
+	if e.history == nil {
+		return 0
+	}
+	return e.history.pos
+}
+
+// Reseed seeds a fraction Stagnation.Density of the cells of the next
+// generation, which starts a new course for the world when rewound.
+func (e *Env) Reseed() {
+	e.reseedNext = true
+}
@@ -0,0 +1,148 @@
+package life
+
+import (
+	"testing"
+)
+
+func withHistory(generations int) func(c *Config) {
+	return func(c *Config) {
+		c.History.Generations = generations
+	}
+}
+
+func equalCells(a, b Cells) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRewind(t *testing.T) {
+	for _, engine := range engineNames {
+		e := newTestEnv(t, 16, 16, withEngine(engine), withHistory(50))
+		gens := []Cells{append(Cells{}, e.cells...)}
+		for i := 0; i < 20; i++ {
+			gens = append(gens, append(Cells{}, e.tick()...))
+		}
+
+		if n := e.Rewind(5); n != 5 || e.Behind() != 5 {
+			t.Fatalf("%s: Rewind(5) = %d, Behind() = %d; want 5, 5", engine,
+				n, e.Behind())
+		}
+		if !equalCells(e.cells, gens[15]) {
+			t.Errorf("%s: rewound generation differs from generation 15",
+				engine)
+		}
+		if !e.StepBack() || !equalCells(e.cells, gens[14]) {
+			t.Errorf("%s: stepped back generation differs from generation 14",
+				engine)
+		}
+
+		// Rewound generations are replayed, then the world carries on as
+		// if it had never been rewound, except for hashlife which loses the
+		// cells outside of the matrix.
+		twin := newTestEnv(t, 16, 16, withEngine(engine), withHistory(50))
+		for i := 0; i < 20; i++ {
+			twin.tick()
+		}
+		for i := 15; i <= 20; i++ {
+			if !equalCells(e.tick(), gens[i]) {
+				t.Fatalf("%s: replayed generation %d differs", engine, i)
+			}
+		}
+		for i := 0; i < 10; i++ {
+			if !equalCells(e.tick(), twin.tick()) && engine != "hashlife" {
+				t.Fatalf("%s: generation %d differs after replaying", engine,
+					21+i)
+			}
+		}
+
+		if n := e.Rewind(1000); n != 30 || !equalCells(e.cells, gens[0]) {
+			t.Errorf("%s: Rewind(1000) = %d; want 30 back to the start", engine,
+				n)
+		}
+		if e.StepBack() {
+			t.Errorf("%s: stepped back past the start", engine)
+		}
+	}
+}
+
+func TestRewindGenerations(t *testing.T) {
+	e := newTestEnv(t, 16, 16, withRule("B2/S/C3"), withHistory(50),
+		func(c *Config) {
+			c.Color.Mode = "truecolor"
+		})
+	gens := []Cells{append(Cells{}, e.cells...)}
+	for i := 0; i < 5; i++ {
+		gens = append(gens, append(Cells{}, e.tick()...))
+	}
+
+	// Dying cells of Generations rules have states beyond the color scheme.
+	if n := e.Rewind(3); n != 3 || !equalCells(e.cells, gens[2]) {
+		t.Errorf("Rewind(3) = %d; want 3 back to generation 2", n)
+	}
+	for i := 3; i <= 5; i++ {
+		if !equalCells(e.tick(), gens[i]) {
+			t.Fatalf("replayed generation %d differs", i)
+		}
+	}
+}
+
+func TestRewindReseed(t *testing.T) {
+	e := newTestEnv(t, 16, 16, withEngine("table"), withHistory(50))
+	for i := 0; i < 20; i++ {
+		e.tick()
+	}
+	e.Rewind(10)
+	e.Reseed()
+	e.tick()
+
+	if e.Behind() != 0 {
+		t.Errorf("Behind() = %d after reseeding; want 0", e.Behind())
+	}
+	if e.History() != 11 {
+		t.Errorf("History() = %d; want 11", e.History())
+	}
+	if e.Forward(1) != 0 {
+		t.Error("replayed a generation dropped by reseeding")
+	}
+}
+
+func TestHistoryBounds(t *testing.T) {
+	e := newTestEnv(t, 16, 16, withEngine("table"), withHistory(50))
+	for i := 0; i < 100; i++ {
+		e.tick()
+	}
+	if e.History() != 50 {
+		t.Errorf("History() = %d; want 50", e.History())
+	}
+
+	h := newHistory(4, 10, 3*generationSize+2*changeSize)
+	h.loaded(Cells{0, 0, 0, 0})
+	for _, cells := range []Cells{{1, 0, 0, 0}, {1, 1, 0, 0}, {1, 1, 1, 0},
+		{1, 1, 1, 1}} {
+		h.push(cells)
+	}
+	if h.n != 2 || h.bytes > h.memory {
+		t.Errorf("generations = %d, bytes = %d; want 2, <= %d", h.n, h.bytes,
+			h.memory)
+	}
+}
+
+func BenchmarkTickHistory256(b *testing.B) {
+	e := newTestEnv(b, 256, 256, withEngine("bitpacked"), withHistory(720))
+	for i := 0; i < 720; i++ {
+		e.tick()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.tick()
+	}
+}
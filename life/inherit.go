@@ -0,0 +1,115 @@
+package life
+
+import (
+	"math/rand"
+)
+
+// An inheritFunc picks the color of a newborn cell from the color counts of
+// its parents.
+type inheritFunc func(cs [LiveCellN]int, r *rand.Rand) int
+
+type inheritance struct {
+	inherit inheritFunc
+	colors  int
+}
+
+var inheritances = map[string]inheritance{
+	"majority":    {inheritMajority, LiveCellN},
+	"random":      {inheritRandom, LiveCellN},
+	"minority":    {inheritMinority, LiveCellN},
+	"new":         {inheritNew, LiveCellN},
+	"immigration": {inheritImmigration, 2},
+}
+
+// pick returns a random color among those for which ok is true, or
+// cellDead if there is none.
+func pick(cs [LiveCellN]int, ok func(int) bool, r *rand.Rand) int {
+	c, n := cellDead, 0
+	for i, v := range cs {
+		if !ok(v) {
+			continue
+		}
+		if n++; r.Intn(n) == 0 {
+			c = i + 1
+		}
+	}
+	return c
+}
+
+// inheritMajority picks the majority color if there is one, otherwise the
+// least represented color, e.g. the missing color of three distinct parents.
+func inheritMajority(cs [LiveCellN]int, r *rand.Rand) int {
+	max, min := 0, 0
+
+	for i, n := range cs {
+		if n > cs[max] {
+			max = i
+		}
+		if n < cs[min] {
+			min = i
+		}
+	}
+
+	for i, n := range cs {
+		if i != max && n == cs[max] {
+			return min + 1
+		}
+	}
+
+	return max + 1
+}
+
+// inheritRandom picks the color of a random parent.
+func inheritRandom(cs [LiveCellN]int, r *rand.Rand) int {
+	n := 0
+	for _, v := range cs {
+		n += v
+	}
+	if n == 0 {
+		return r.Intn(LiveCellN) + 1
+	}
+
+	p := r.Intn(n)
+	for i, v := range cs {
+		if p -= v; p < 0 {
+			return i + 1
+		}
+	}
+
+	return cellDead
+}
+
+// inheritMinority picks the color of the least represented parents.
+func inheritMinority(cs [LiveCellN]int, r *rand.Rand) int {
+	min := 0
+	for _, v := range cs {
+		if v > 0 && (min == 0 || v < min) {
+			min = v
+		}
+	}
+	if min == 0 {
+		return inheritRandom(cs, r)
+	}
+
+	return pick(cs, func(v int) bool { return v == min }, r)
+}
+
+// inheritNew picks a color none of the parents have, if any.
+func inheritNew(cs [LiveCellN]int, r *rand.Rand) int {
+	if c := pick(cs, func(v int) bool { return v == 0 }, r); c != cellDead {
+		return c
+	}
+	return inheritRandom(cs, r)
+}
+
+// inheritImmigration picks the majority color of the two colors of the
+// Immigration rule, breaking ties randomly.
+func inheritImmigration(cs [LiveCellN]int, r *rand.Rand) int {
+	switch {
+	case cs[0] > cs[1]:
+		return cellLive1
+	case cs[1] > cs[0]:
+		return cellLive2
+	}
+	return r.Intn(2) + 1
+}
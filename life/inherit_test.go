@@ -0,0 +1,78 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestInheritMajority(t *testing.T) {
+	counts := [...][LiveCellN]int{
+		{2, 1, 0, 0},
+		{1, 1, 1, 0},
+		{0, 1, 1, 1},
+		{0, 0, 6, 0},
+		{3, 3, 0, 0},
+		{1, 0, 1, 0},
+	}
+	want := [...]int{1, 4, 1, 3, 3, 2}
+
+	for i, cs := range counts {
+		if c := inheritMajority(cs, nil); c != want[i] {
+			t.Errorf("inheritMajority(%v) = %d; want %d", cs, c, want[i])
+		}
+	}
+}
+
+func testInherit(t *testing.T, name string, f inheritFunc,
+	cs [LiveCellN]int, want ...int) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		c := f(cs, r)
+		ok := false
+		for _, w := range want {
+			ok = ok || c == w
+		}
+		if !ok {
+			t.Fatalf("%s(%v) = %d; want one of %v", name, cs, c, want)
+		}
+	}
+}
+
+func TestInheritRandom(t *testing.T) {
+	testInherit(t, "inheritRandom", inheritRandom, [LiveCellN]int{2, 0, 1, 0},
+		cellLive1, cellLive3)
+}
+
+func TestInheritMinority(t *testing.T) {
+	testInherit(t, "inheritMinority", inheritMinority,
+		[LiveCellN]int{2, 0, 1, 0}, cellLive3)
+	testInherit(t, "inheritMinority", inheritMinority,
+		[LiveCellN]int{1, 1, 0, 1}, cellLive1, cellLive2, cellLive4)
+}
+
+func TestInheritNew(t *testing.T) {
+	testInherit(t, "inheritNew", inheritNew, [LiveCellN]int{2, 0, 1, 0},
+		cellLive2, cellLive4)
+	testInherit(t, "inheritNew", inheritNew, [LiveCellN]int{1, 1, 1, 1},
+		cellLive1, cellLive2, cellLive3, cellLive4)
+}
+
+func TestInheritImmigration(t *testing.T) {
+	testInherit(t, "inheritImmigration", inheritImmigration,
+		[LiveCellN]int{1, 2, 0, 0}, cellLive2)
+	testInherit(t, "inheritImmigration", inheritImmigration,
+		[LiveCellN]int{3, 3, 0, 0}, cellLive1, cellLive2)
+}
+
+func TestEnvRandomizeImmigration(t *testing.T) {
+	e := newTestEnv(t, testWidth, testHeight, func(c *Config) {
+		c.Inheritance = "immigration"
+	})
+
+	for i, v := range e.cells {
+		if v > cellLive2 {
+			t.Fatalf("cell[%d] = %d; want <= %d", i, v, cellLive2)
+		}
+	}
+}
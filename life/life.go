@@ -3,6 +3,7 @@ package life
 import (
 	"image/color"
 	"math/rand"
+	"time"
 )
 
 const (
@@ -17,6 +18,20 @@ const (
 
 const LiveCellN = cellN - 1
 
+// Cells beyond cellLive4 are dying cells of Generations rules, encoding
+// their dying stage and the color they had when alive.
+func isLive(c int) bool {
+	return c != cellDead && c < cellN
+}
+
+func cellColor(c int) int {
+	return (c-1)%LiveCellN + 1
+}
+
+func cellStage(c int) int {
+	return (c - 1) / LiveCellN
+}
+
 var colorScheme = ColorScheme{
 	color.Black,
 	color.RGBA{255, 0, 0, 255},
@@ -34,18 +49,55 @@ var logger Logger
 
 type ColorScheme [cellN]color.Color
 type Cells []int
-type Neighbors [8]int
+type Neighbors []int32
+
+// A wrapFunc maps coordinates outside the grid back onto it. It returns
+// false if the coordinates fall beyond a dead border.
+type wrapFunc func(x, y, width, height int) (int, int, bool)
+
+var topologies = map[string]wrapFunc{
+	"torus":   wrapTorus,
+	"bounded": wrapBounded,
+	"klein":   wrapKlein,
+	"cross":   wrapCross,
+	"mirror":  wrapMirror,
+}
 
 type Env struct {
 	cells                   Cells
 	buffer                  Cells
+	colors                  []color.RGBA
+	colorBuffer             []color.RGBA
+	ages                    []uint16
+	ageBuffer               []uint16
 	deadZones               Cells
+	neighbors               Neighbors
+	stride                  int
+	palette                 []color.Color
+	paletteScheme           ColorScheme
 	width                   int
 	height                  int
 	size                    int
+	cellWidth               int
 	seedThreshold           float32
 	seedThresholdDecayTicks int
 	seedCooldownTicks       int
+	rule                    rule
+	offsets                 [2][]offset
+	wrap                    wrapFunc
+	inheritance             inheritance
+	engine                  engine
+	automaton               Automaton
+	stagnation              *stagnation
+	library                 [][]offset
+	randSeed                int64
+	rand                    *rand.Rand
+	paletteRand             *rand.Rand
+	suspended               time.Time
+	recorder                *Recorder
+	history                 *history
+	reseedNext              bool
+	bands                   []*band
 	config                  *Config
 }
 
@@ -62,46 +114,82 @@ func SetColorScheme(cs ColorScheme) {
 	colorScheme = cs
 }
 
+// NewEnv makes an Env drawing from random sources seeded with Config.Seed,
+// or with the current time if unset.
 func NewEnv(c *Config) *Env {
-	size := c.Hardware.MatrixWidth * c.Hardware.MatrixHeight
+	seed := c.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	e := NewEnvSource(c, rand.NewSource(seed))
+	e.randSeed = seed
 
-	return &Env{
+	return e
+}
+
+// NewEnvSource makes an Env drawing from src, its seed being unknown.
+func NewEnvSource(c *Config, src rand.Source) *Env {
+	// Hexagonal cells are two pixels wide so that odd rows can be offset by
+	// half a cell.
+	cw := 1
+	if c.hexagonal() {
+		cw = 2
+	}
+	width := c.Hardware.MatrixWidth / cw
+	size := width * c.Hardware.MatrixHeight
+
+	e := &Env{
 		cells:                   make(Cells, size),
 		buffer:                  make(Cells, size),
 		deadZones:               make(Cells, 0, size),
-		width:                   c.Hardware.MatrixWidth,
+		width:                   width,
 		height:                  c.Hardware.MatrixHeight,
 		size:                    size,
+		cellWidth:               cw,
 		seedThreshold:           c.SeedThreshold,
 		seedThresholdDecayTicks: 0,
 		seedCooldownTicks:       0,
+		rule:                    c.rule,
+		offsets:                 c.rule.offsets(),
+		wrap:                    topologies[c.Topology],
+		inheritance:             inheritances[c.Inheritance],
 		config:                  c,
 	}
-}
 
-func applyRules(c, n int, cs [LiveCellN]int) int {
-	if n > 3 || n < 2 {
-		return cellDead
-	}
+	// Palettes are generated from their own source, seeded from src, so
+	// that regenerating them does not change the course of the world.
+	e.rand = rand.New(src)
+	e.paletteRand = rand.New(rand.NewSource(e.rand.Int63()))
 
-	if c == cellDead && n == 3 {
-		for s, i := range cs {
-			s += 1
-			if i > 1 {
-				return s
-			}
-			if i == 0 {
-				c = s
-			}
+	e.bands = newBands(width, e.height, c.workers())
+
+	// Automata other than life only use the engine to set cells, which the
+	// table engine ignores.
+	e.engine = &tableEngine{}
+	e.automaton = automata[c.Automaton](e)
+
+	e.library = library
+	for _, p := range c.patterns {
+		if p.Width <= e.width && p.Height <= e.height {
+			e.library = append(e.library, patternOffsets(p))
 		}
 	}
 
-	return c
+	if c.Stagnation.MaxPeriod > 0 {
+		e.stagnation = newStagnation(c.Stagnation.MaxPeriod)
+	}
+
+	if c.History.Generations > 0 {
+		e.history = newHistory(size, c.History.Generations,
+			c.History.Memory*1024)
+	}
+
+	return e
 }
 
-func randomCell() int {
-	if rand.Intn(2) == 1 {
-		return rand.Intn(LiveCellN) + 1
+func randomCell(colors int, r *rand.Rand) int {
+	if r.Intn(2) == 1 {
+		return r.Intn(colors) + 1
 	}
 	return cellDead
 }
@@ -114,26 +202,81 @@ func getCoords(idx, width int) (int, int) {
 	return idx % width, idx / width
 }
 
-func getNeighbors(idx, width, height int) (ns Neighbors) {
+func mod(a, n int) int {
+	return (a%n + n) % n
+}
+
+func wrapTorus(x, y, width, height int) (int, int, bool) {
+	return mod(x, width), mod(y, height), true
+}
+
+func wrapBounded(x, y, width, height int) (int, int, bool) {
+	return x, y, x >= 0 && x < width && y >= 0 && y < height
+}
+
+func wrapKlein(x, y, width, height int) (int, int, bool) {
+	if y < 0 || y >= height {
+		x = width - 1 - x
+	}
+	return wrapTorus(x, y, width, height)
+}
+
+func wrapCross(x, y, width, height int) (int, int, bool) {
+	if x < 0 || x >= width {
+		y = height - 1 - y
+	}
+	if y < 0 || y >= height {
+		x = width - 1 - x
+	}
+	return wrapTorus(x, y, width, height)
+}
+
+func mirror(a, n int) int {
+	if a < 0 {
+		return -a - 1
+	}
+	if a >= n {
+		return 2*n - a - 1
+	}
+	return a
+}
+
+func wrapMirror(x, y, width, height int) (int, int, bool) {
+	return mirror(x, width), mirror(y, height), true
+}
+
+// getNeighbors returns the indices of the neighbors of idx at the given
+// offsets for its row, or -1 for neighbors beyond a dead border.
+func getNeighbors(idx, width, height int, os [2][]offset,
+	wrap wrapFunc) Neighbors {
+	ns := make(Neighbors, 0, len(os[idx/width&1]))
+
+	return appendNeighbors(ns, idx, width, height, os, wrap)
+}
+
+// appendNeighbors appends the neighbors of idx to ns, as getNeighbors
+// returns them.
+func appendNeighbors(ns Neighbors, idx, width, height int, os [2][]offset,
+	wrap wrapFunc) Neighbors {
 	x, y := getCoords(idx, width)
-	i := 0
 
-	for _, w := range [...]int{width - 1, 0, 1} {
-		for _, h := range [...]int{height - 1, 0, 1} {
-			if w == 0 && h == 0 {
-				continue
-			}
-			ns[i] = getIdx((x+w)%width, (y+h)%height, width)
-			i++
+	for _, o := range os[y&1] {
+		n := int32(-1)
+		if nx, ny, ok := wrap(x+o.x, y+o.y, width, height); ok {
+			n = int32(getIdx(nx, ny, width))
 		}
+		ns = append(ns, n)
 	}
 
 	return ns
 }
 
-func getContext(cells Cells, ns [8]int) (n int, cs [LiveCellN]int) {
+func getContext(cells Cells, ns Neighbors) (n int, cs [LiveCellN]int) {
 	for _, i := range ns {
-		if c := cells[i]; c != cellDead {
+		if i < 0 {
+			continue
+		}
+		if c := cells[i]; isLive(c) {
 			n += 1
 			cs[c-1] += 1
 		}
@@ -142,43 +285,75 @@ func getContext(cells Cells, ns [8]int) (n int, cs [LiveCellN]int) {
 	return n, cs
 }
 
-func (e *Env) getNeighbors(idx int) Neighbors {
-	return getNeighbors(idx, e.width, e.height)
+func (e *Env) randomCell() int {
+	return randomCell(e.inheritance.colors, e.rand)
 }
 
-func (e *Env) updateDeadZones() int {
-	e.deadZones = e.deadZones[:0]
+// seedCell sets cell idx of the next generation to a random state.
+func (e *Env) seedCell(idx int) {
+	e.SetCell(idx, e.randomCell())
+}
 
-	for i := range e.buffer {
-		if e.buffer[i] != cellDead {
-			continue
-		}
-		ns := e.getNeighbors(i)
-		if n, _ := getContext(e.buffer, ns); n == 0 {
-			e.deadZones = append(e.deadZones, i)
-		}
+// SetCell sets cell idx of the next generation to state c.
+func (e *Env) SetCell(idx, c int) {
+	e.buffer[idx] = c
+	if e.colors != nil {
+		e.colorBuffer[idx] = schemeColor(c)
+	}
+	if e.ages != nil {
+		e.ageBuffer[idx] = 0
 	}
+	e.engine.set(idx, c)
+}
+
+// Neighbor tables are only kept up to this many neighbors, taking 16 MB;
+// neighbors of larger radii on large matrices are computed on every tick.
+const maxNeighbors = 1 << 22
 
-	return len(e.deadZones)
+// initNeighbors fills the neighbor table, holding the neighbors of every
+// cell so that they are not recomputed on each tick, unless it would be too
+// large.
+func (e *Env) initNeighbors() {
+	e.stride = len(e.offsets[0])
+	if e.size*e.stride > maxNeighbors {
+		return
+	}
+	e.neighbors = make(Neighbors, 0, e.size*e.stride)
+
+	for i := 0; i < e.size; i++ {
+		e.neighbors = appendNeighbors(e.neighbors, i, e.width, e.height,
+			e.offsets, e.wrap)
+	}
 }
 
-func (e *Env) seedDeadZones() {
-	i := e.deadZones[rand.Intn(len(e.deadZones))]
-	e.buffer[i] = randomCell()
+func (e *Env) getNeighbors(idx int) Neighbors {
+	if e.neighbors == nil {
+		return getNeighbors(idx, e.width, e.height, e.offsets, e.wrap)
+	}
+	return e.neighbors[idx*e.stride : (idx+1)*e.stride]
+}
 
-	for _, n := range e.getNeighbors(i) {
-		e.buffer[n] = randomCell()
+// bandNeighbors returns the neighbors of idx like getNeighbors, computing
+// them into the scratch space of band b without a neighbor table.
+func (e *Env) bandNeighbors(b *band, idx int) Neighbors {
+	if e.neighbors == nil {
+		b.scratch = appendNeighbors(b.scratch[:0], idx, e.width, e.height,
+			e.offsets, e.wrap)
+		return b.scratch
 	}
+	return e.neighbors[idx*e.stride : (idx+1)*e.stride]
 }
 
 func (e *Env) seed() {
 	if e.seedCooldownTicks > 0 {
 		e.seedCooldownTicks--
-		logger.log("seed", "cooldown = %d\n", e.seedCooldownTicks)
+		if debug {
+			logger.log("seed", "cooldown = %d\n", e.seedCooldownTicks)
+		}
 		return
 	}
 
-	z := e.updateDeadZones()
+	z := len(e.deadZones)
 	if z == 0 {
 		return
 	}
@@ -187,48 +362,223 @@ func (e *Env) seed() {
 	c := e.config
 
 	if t >= e.seedThreshold || e.seedThreshold < c.SeedThresholdDecay {
-		logger.log("seed", "deadzones = %f; seeding...\n", t)
-		e.seedDeadZones()
+		s := pickSeeder(c.seeders, e.rand)
+		if debug {
+			logger.log("seed", "deadzones = %f; seeding %s...\n", t, s.name)
+		}
+		e.event("seed %s", s.name)
+		s.seeder.Seed(e)
 		e.seedThreshold = c.SeedThreshold
 		e.seedThresholdDecayTicks = c.SeedThresholdDecayTicks
 		e.seedCooldownTicks = c.SeedCooldownTicks
 	} else if e.seedThresholdDecayTicks > 0 {
 		e.seedThresholdDecayTicks--
-		logger.log("seed", "decay = %d\n", e.seedThresholdDecayTicks)
+		if debug {
+			logger.log("seed", "decay = %d\n", e.seedThresholdDecayTicks)
+		}
 	} else {
 		e.seedThreshold -= e.config.SeedThresholdDecay
 		e.seedThresholdDecayTicks = c.SeedThresholdDecayTicks
-		logger.log("seed", "threshold = %f\n", e.seedThreshold)
+		if debug {
+			logger.log("seed", "threshold = %f\n", e.seedThreshold)
+		}
 	}
 }
 
+// Seed returns the seed of the random sources of the Env, from which the
+// same session can be replayed, or 0 if it was made with NewEnvSource.
+func (e *Env) Seed() int64 {
+	return e.randSeed
+}
+
+// Rand returns the random source of the Env, which seeders should use so
+// that sessions can be replayed.
+func (e *Env) Rand() *rand.Rand {
+	return e.rand
+}
+
+func (e *Env) Width() int {
+	return e.width
+}
+
+func (e *Env) Height() int {
+	return e.height
+}
+
+// DeadZones returns the dead cells of the current generation without live
+// neighbors.
+func (e *Env) DeadZones() Cells {
+	return e.deadZones
+}
+
 func (e *Env) tick() Cells {
-	for i := range e.buffer {
-		n, cs := getContext(e.cells, e.getNeighbors(i))
-		e.buffer[i] = applyRules(e.cells[i], n, cs)
+	if h := e.history; h != nil {
+		// Rewound generations are replayed, unless the world is reseeded.
+		if !e.reseedNext && h.forward(e) {
+			if e.recorder != nil {
+				e.recorder.frame(e)
+			}
+			return e.cells
+		}
+		h.sync(e)
+	}
+
+	e.automaton.Step(e)
+	if e.reseedNext {
+		e.reseedNext = false
+		e.event("reseed")
+		e.automaton.Reseed(e, e.config.Stagnation.Density)
+	}
+	e.automaton.Seed(e)
+	e.swap()
+	e.record()
+	if e.recorder != nil {
+		e.recorder.frame(e)
 	}
-	e.seed()
-	copy(e.cells, e.buffer)
 
 	return e.cells
 }
 
+func (e *Env) swap() {
+	e.cells, e.buffer = e.buffer, e.cells
+	e.colors, e.colorBuffer = e.colorBuffer, e.colors
+	e.ages, e.ageBuffer = e.ageBuffer, e.ages
+}
+
+// Engines stepping through every generation advance by this many cells at
+// most at once, so that the display does not freeze on large matrices.
+const maxAdvanceCells = 1 << 24
+
+// Advance jumps n generations ahead without seeding, returning the number of
+// generations advanced. The hashlife engine computes the result in time
+// logarithmic in n for most patterns; other engines step through every
+// generation, up to maxAdvanceCells.
+func (e *Env) Advance(n int) int {
+	if e.history != nil {
+		e.history.sync(e)
+	}
+	a, ok := e.engine.(advancer)
+	if max := maxAdvanceCells / e.size; !ok && n > max {
+		n = max
+	}
+	e.event("advance %d", n)
+	if ok {
+		a.advance(e, n)
+		e.swap()
+	} else {
+		for g := 0; g < n; g++ {
+			e.automaton.Step(e)
+			e.swap()
+		}
+	}
+
+	e.record()
+	if e.recorder != nil {
+		e.recorder.frame(e)
+	}
+	return n
+}
+
 func (e *Env) Randomize() {
+	e.automaton.Randomize(e)
+	e.load()
+}
+
+// load resets the colors and ages of the cells, and loads them into the
+// automaton.
+func (e *Env) load() {
 	for i := range e.cells {
-		e.cells[i] = randomCell()
+		if e.colors != nil {
+			e.colors[i] = schemeColor(e.cells[i])
+		}
+		if e.ages != nil {
+			e.ages[i] = 0
+		}
+	}
+	e.automaton.Load(e)
+	if e.history != nil {
+		e.history.loaded(e.cells)
+	}
+}
+
+func dim(c color.Color, f float64) color.Color {
+	r, g, b, _ := c.RGBA()
+	return color.RGBA64{
+		uint16(float64(r) * f),
+		uint16(float64(g) * f),
+		uint16(float64(b) * f),
+		0xffff,
+	}
+}
+
+// brightness returns the brightness of a cell relative to live cells.
+func (e *Env) brightness(c int) float64 {
+	return 1.0 - float64(cellStage(c))/float64(e.rule.states-1)
+}
+
+// updatePalette maps every cell state to a color, dying cells being dimmed
+// versions of the color they had when alive. The palette is only rebuilt
+// when the color scheme changes.
+func (e *Env) updatePalette() []color.Color {
+	if e.palette != nil && e.paletteScheme == colorScheme {
+		return e.palette
+	}
+
+	p := make([]color.Color, (e.rule.states-1)*LiveCellN+1)
+	copy(p, colorScheme[:])
+
+	for c := cellN; c < len(p); c++ {
+		p[c] = dim(colorScheme[cellColor(c)], e.brightness(c))
+	}
+
+	e.palette = p
+	e.paletteScheme = colorScheme
+
+	return p
+}
+
+func (e *Env) color(p []color.Color, idx, c int) color.Color {
+	if e.ages != nil && isLive(c) {
+		return dim(p[c], e.ageBrightness(e.ages[idx]))
+	}
+	if e.colors == nil || c == cellDead {
+		return p[c]
+	}
+	if isLive(c) {
+		return e.colors[idx]
+	}
+	return dim(e.colors[idx], e.brightness(c))
+}
+
+func (e *Env) set(r Renderer, idx int, c color.Color) {
+	x, y := getCoords(idx, e.width)
+	x *= e.cellWidth
+	if e.cellWidth > 1 {
+		x += y & 1
+	}
+
+	for px := x; px < x+e.cellWidth; px++ {
+		if px < e.config.Hardware.MatrixWidth {
+			r.Set(px, y, c)
+		}
 	}
 }
 
 func (e *Env) Update(r Renderer) {
-	for i, c := range e.tick() {
-		x, y := getCoords(i, e.width)
-		r.Set(x, y, colorScheme[c])
+	e.tick()
+	e.Draw(r)
+}
+
+// Draw renders the current generation.
+func (e *Env) Draw(r Renderer) {
+	for i, c := range e.cells {
+		e.set(r, i, e.automaton.Color(e, i, c))
 	}
 	r.Render()
 }
 
 func (e *Env) Clear(r Renderer) {
-	for x := 0; x < e.width; x++ {
+	for x := 0; x < e.config.Hardware.MatrixWidth; x++ {
 		for y := 0; y < e.height; y++ {
 			r.Set(x, y, color.Black)
 		}
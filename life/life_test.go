@@ -1,7 +1,11 @@
 package life
 
 import (
+	"image/color"
+	"math/rand"
 	"testing"
+
+	"lifelight/pattern"
 )
 
 const (
@@ -26,12 +30,14 @@ var (
 	}
 	gliderWidth  = 5
 	gliderHeight = 5
+
+	conway, _ = parseRule("B3/S23")
 )
 
-func testGetNeighbors(t *testing.T, idx int, vals []int) {
-	ns := getNeighbors(idx, testWidth, testHeight)
+func testGetNeighbors(t *testing.T, idx int, wrap wrapFunc, vals []int) {
+	ns := getNeighbors(idx, testWidth, testHeight, conway.offsets(), wrap)
 	for i, v := range vals {
-		if ns[i] != v {
+		if int(ns[i]) != v {
 			t.Errorf("neighbors[%d] = %d; want %d", i, ns[i], v)
 		}
 	}
@@ -52,16 +58,32 @@ func TestGetCoords(t *testing.T) {
 }
 
 func TestGetNeighbors(t *testing.T) {
-	testGetNeighbors(t, 408, []int{375, 407, 439, 376, 440, 377, 409, 441})
+	testGetNeighbors(t, 408, wrapTorus, []int{375, 407, 439, 376, 440, 377, 409, 441})
 }
 
 func TestGetNeighborsEdge(t *testing.T) {
-	testGetNeighbors(t, 1023, []int{990, 1022, 30, 991, 31, 960, 992, 0})
+	testGetNeighbors(t, 1023, wrapTorus, []int{990, 1022, 30, 991, 31, 960, 992, 0})
+}
+
+func TestGetNeighborsBounded(t *testing.T) {
+	testGetNeighbors(t, 0, wrapBounded, []int{-1, -1, -1, -1, 32, -1, 1, 33})
+}
+
+func TestGetNeighborsKlein(t *testing.T) {
+	testGetNeighbors(t, 0, wrapKlein, []int{992, 31, 63, 1023, 32, 1022, 1, 33})
+}
+
+func TestGetNeighborsCross(t *testing.T) {
+	testGetNeighbors(t, 0, wrapCross, []int{0, 1023, 991, 1023, 32, 1022, 1, 33})
+}
+
+func TestGetNeighborsMirror(t *testing.T) {
+	testGetNeighbors(t, 0, wrapMirror, []int{0, 0, 32, 0, 32, 1, 1, 33})
 }
 
 func TestGetContext(t *testing.T) {
 	idx := getIdx(2, 2, gliderWidth)
-	ns := getNeighbors(idx, gliderWidth, gliderHeight)
+	ns := getNeighbors(idx, gliderWidth, gliderHeight, conway.offsets(), wrapTorus)
 	n, cs := getContext(glider0, ns)
 
 	if n != 5 {
@@ -79,9 +101,9 @@ func TestApplyRules(t *testing.T) {
 	cells := make(Cells, gliderWidth*gliderHeight)
 
 	for i, c := range glider0 {
-		ns := getNeighbors(i, gliderWidth, gliderHeight)
+		ns := getNeighbors(i, gliderWidth, gliderHeight, conway.offsets(), wrapTorus)
 		n, cs := getContext(glider0, ns)
-		cells[i] = applyRules(c, n, cs)
+		cells[i] = conway.apply(c, n, cs, inheritMajority, nil)
 	}
 
 	for i, c := range cells {
@@ -90,3 +112,242 @@ func TestApplyRules(t *testing.T) {
 		}
 	}
 }
+
+type testRenderer struct {
+	width  int
+	pixels []color.Color
+}
+
+func newTestRenderer(width, height int) *testRenderer {
+	return &testRenderer{width, make([]color.Color, width*height)}
+}
+
+func (r *testRenderer) Set(x, y int, c color.Color) {
+	r.pixels[getIdx(x, y, r.width)] = c
+}
+
+func (r *testRenderer) Render() error {
+	return nil
+}
+
+func TestEnvSetHex(t *testing.T) {
+	e := newTestEnv(t, 4, 2, withRule("B2/S34H"))
+	if e.width != 2 {
+		t.Fatalf("width = %d; want 2", e.width)
+	}
+
+	r := newTestRenderer(4, 2)
+	for i := 0; i < e.size; i++ {
+		e.set(r, i, colorScheme[i+1])
+	}
+
+	want := []color.Color{
+		colorScheme[1], colorScheme[1], colorScheme[2], colorScheme[2],
+		nil, colorScheme[3], colorScheme[3], colorScheme[4],
+	}
+	for i, p := range r.pixels {
+		if p != want[i] {
+			t.Errorf("pixel[%d] = %v; want %v", i, p, want[i])
+		}
+	}
+}
+
+// naiveTick computes the next generation the way the neighbor table avoids:
+// recomputing the neighbors of every cell.
+func naiveTick(e *Env) {
+	for i := range e.buffer {
+		ns := getNeighbors(i, e.width, e.height, e.offsets, e.wrap)
+		n, cs := getContext(e.cells, ns)
+		e.buffer[i] = e.rule.apply(e.cells[i], n, cs, e.inheritance.inherit, nil)
+	}
+	e.deadZones = e.deadZones[:0]
+	for i := range e.buffer {
+		if e.buffer[i] != cellDead {
+			continue
+		}
+		ns := getNeighbors(i, e.width, e.height, e.offsets, e.wrap)
+		if n, _ := getContext(e.buffer, ns); n == 0 {
+			e.deadZones = append(e.deadZones, i)
+		}
+	}
+	copy(e.cells, e.buffer)
+}
+
+// newTestEnv returns a world of width by height random cells, seeded with 1
+// and never seeding itself, configured by the given functions.
+func newTestEnv(t testing.TB, width, height int,
+	configure ...func(c *Config)) *Env {
+	c := NewConfig()
+	c.Seed = 1
+	c.Hardware.MatrixWidth = width
+	c.Hardware.MatrixHeight = height
+	for _, f := range configure {
+		f(c)
+	}
+
+	var err error
+	if c.rule, err = parseRule(c.Rule); err != nil {
+		t.Fatalf("Rule = %s; %v", c.Rule, err)
+	}
+	if c.seeders, err = parseSeeders(c.Seeders); err != nil {
+		t.Fatalf("Seeders = %v; %v", c.Seeders, err)
+	}
+	if c.turmite, err = parseTurmite(c.Turmite.Rule); err != nil {
+		t.Fatalf("Turmite.Rule = %s; %v", c.Turmite.Rule, err)
+	}
+
+	e := NewEnv(c)
+	e.seedCooldownTicks = 1 << 30
+	e.Reset()
+
+	return e
+}
+
+func withRule(rule string) func(c *Config) {
+	return func(c *Config) {
+		c.Rule = rule
+	}
+}
+
+// withBlank makes the cells of the world dead rather than random.
+func withBlank(c *Config) {
+	c.initial = &pattern.Pattern{Width: 1, Height: 1, Cells: []int{0}}
+}
+
+func withTopology(topology string) func(c *Config) {
+	return func(c *Config) {
+		c.Topology = topology
+	}
+}
+
+func withEngine(engine string) func(c *Config) {
+	return func(c *Config) {
+		c.Engine = engine
+	}
+}
+
+func TestTickNeighborTable(t *testing.T) {
+	for _, r := range [...]string{"B3/S23", "B2/S34H", "R2,C0,M1,S4..9,B5..7"} {
+		for topology := range topologies {
+			e := newTestEnv(t, testWidth, testHeight, withRule(r),
+				withTopology(topology))
+			// Neighbors are computed on the fly without a table.
+			f := newTestEnv(t, testWidth, testHeight, withRule(r),
+				withTopology(topology))
+			f.neighbors = nil
+			naive := newTestEnv(t, testWidth, testHeight, withRule(r),
+				withTopology(topology))
+			copy(f.cells, e.cells)
+			copy(naive.cells, e.cells)
+
+			for g := 0; g < 20; g++ {
+				e.tick()
+				f.tick()
+				naiveTick(naive)
+			}
+
+			for i, c := range e.cells {
+				if c != naive.cells[i] || f.cells[i] != naive.cells[i] {
+					t.Fatalf("%s on %s: cell[%d] = %d, %d; want %d",
+						r, topology, i, c, f.cells[i], naive.cells[i])
+				}
+			}
+			// Dead zones are those of the generation stepped to.
+			if !equalCells(e.deadZones, naive.deadZones) ||
+				!equalCells(f.deadZones, naive.deadZones) {
+				t.Fatalf("%s on %s: deadzones = %v, %v; want %v", r,
+					topology, e.deadZones, f.deadZones, naive.deadZones)
+			}
+		}
+	}
+}
+
+func TestNeighborTableLimit(t *testing.T) {
+	e := newTestEnv(t, 256, 256, withRule("R10,C0,M1,S100..200,B150..200"))
+	if e.neighbors != nil {
+		t.Errorf("neighbor table = %d neighbors; want none over %d",
+			len(e.neighbors), maxNeighbors)
+	}
+}
+
+func BenchmarkTick(b *testing.B) {
+	e := newTestEnv(b, 128, 64, withRule("B3/S23"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.tick()
+	}
+}
+
+func BenchmarkTickNaive(b *testing.B) {
+	e := newTestEnv(b, 128, 64, withRule("B3/S23"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		naiveTick(e)
+	}
+}
+
+func TestSeedReplay(t *testing.T) {
+	run := func() *Env {
+		e := newTestEnv(t, testWidth, testHeight, func(c *Config) {
+			c.Seed = 42
+			c.Seeders = []string{"deadzone", "spaceship", "rain"}
+			c.Stagnation.MaxPeriod = 15
+			c.Stagnation.Ticks = 2
+		})
+		e.seedCooldownTicks = 0
+		for g := 0; g < 200; g++ {
+			e.tick()
+		}
+		return e
+	}
+
+	e, r := run(), run()
+	if e.Seed() != 42 {
+		t.Errorf("seed = %d; want 42", e.Seed())
+	}
+	for i, c := range r.cells {
+		if c != e.cells[i] {
+			t.Fatalf("cell[%d] = %d; want %d", i, c, e.cells[i])
+		}
+	}
+}
+
+func TestAdvanceLimit(t *testing.T) {
+	e := newTestEnv(t, 128, 128)
+	s := newTestEnv(t, 128, 128)
+
+	n := e.Advance(1 << 30)
+	if want := maxAdvanceCells / e.size; n != want {
+		t.Fatalf("generations = %d; want %d", n, want)
+	}
+	for g := 0; g < n; g++ {
+		s.tick()
+	}
+	if !equalCells(e.cells, s.cells) {
+		t.Error("advanced world differs from the stepped one")
+	}
+}
+
+func TestNewEnvSource(t *testing.T) {
+	run := func(e *Env) *Env {
+		e.Randomize()
+		for g := 0; g < 50; g++ {
+			e.tick()
+		}
+		return e
+	}
+
+	c := NewConfig()
+	e := run(NewEnvSource(c, rand.NewSource(5)))
+	if e.Seed() != 0 {
+		t.Errorf("seed = %d; want 0", e.Seed())
+	}
+	c.Seed = 5
+	if r := run(NewEnv(c)); !equalCells(r.cells, e.cells) {
+		t.Error("world differs from the one seeded with the same seed")
+	}
+}
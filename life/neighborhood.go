@@ -0,0 +1,64 @@
+package life
+
+type neighborhood int
+
+const (
+	moore neighborhood = iota
+	vonNeumann
+	circular
+	hexagonal
+)
+
+const maxRadius = 10
+
+type offset struct {
+	x int
+	y int
+}
+
+// axial converts the column of a cell on a hexagonal grid laid out with odd
+// rows shifted right to its axial coordinate.
+func axial(x, y int) int {
+	return x - (y-(y&1))/2
+}
+
+func hexDistance(x, y, dy int) int {
+	q := axial(x, y+dy) - axial(0, y)
+	return (abs(q) + abs(dy) + abs(q+dy)) / 2
+}
+
+func (nh neighborhood) contains(x, y, row, radius int) bool {
+	switch nh {
+	case vonNeumann:
+		return abs(x)+abs(y) <= radius
+	case circular:
+		return 4*(x*x+y*y) <= (2*radius+1)*(2*radius+1)
+	case hexagonal:
+		return hexDistance(x, row, y) <= radius
+	}
+	return true
+}
+
+// offsets returns the relative positions of the neighbors within radius,
+// column by column from the top left, for even and odd rows.
+func (nh neighborhood) offsets(radius int) (os [2][]offset) {
+	for row := range os {
+		for x := -radius; x <= radius; x++ {
+			for y := -radius; y <= radius; y++ {
+				if x == 0 && y == 0 || !nh.contains(x, y, row, radius) {
+					continue
+				}
+				os[row] = append(os[row], offset{x, y})
+			}
+		}
+	}
+
+	return os
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,56 @@
+package life
+
+import (
+	"testing"
+)
+
+func TestNeighborhoodOffsets(t *testing.T) {
+	tests := []struct {
+		nh     neighborhood
+		radius int
+		want   int
+	}{
+		{moore, 1, 8},
+		{moore, 5, 120},
+		{vonNeumann, 1, 4},
+		{vonNeumann, 2, 12},
+		{circular, 1, 8},
+		{circular, 3, 36},
+		{hexagonal, 1, 6},
+		{hexagonal, 2, 18},
+	}
+
+	for _, tt := range tests {
+		if n := len(tt.nh.offsets(tt.radius)[0]); n != tt.want {
+			t.Errorf("len(offsets(%d, %d)) = %d; want %d",
+				tt.nh, tt.radius, n, tt.want)
+		}
+	}
+}
+
+func TestNeighborhoodOffsetsOrder(t *testing.T) {
+	want := []offset{{-1, 0}, {0, -1}, {0, 1}, {1, 0}}
+
+	for i, o := range vonNeumann.offsets(1)[0] {
+		if o != want[i] {
+			t.Errorf("offset[%d] = %v; want %v", i, o, want[i])
+		}
+	}
+}
+
+func TestNeighborhoodOffsetsHex(t *testing.T) {
+	want := [2][]offset{
+		{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, 0}},
+		{{-1, 0}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}},
+	}
+	os := hexagonal.offsets(1)
+
+	for row := range want {
+		for i, o := range os[row] {
+			if o != want[row][i] {
+				t.Errorf("row %d: offset[%d] = %v; want %v",
+					row, i, o, want[row][i])
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// paletteRanges bound the chroma and lightness of the colors of each palette,
+// after the palette generators of go-colorful.
+var paletteRanges = map[string]struct{ chroma, light [2]float64 }{
+	"happy": {chroma: [2]float64{0.3, 0.6}, light: [2]float64{0.4, 0.8}},
+	"soft":  {chroma: [2]float64{0.1, 0.5}, light: [2]float64{0.3, 0.9}},
+	"warm":  {chroma: [2]float64{0.1, 0.4}, light: [2]float64{0.2, 0.5}},
+}
+
+// paletteTries is the number of HCL samples tried for each color before the
+// last one is clamped into the RGB gamut.
+const paletteTries = 16
+
+// GenColors sets the color scheme to a palette generated from one of
+// Color.Palettes, picked at random. The hues of the palette are evenly spaced
+// from a random start; chroma and lightness are sampled within the ranges of
+// the palette. Palettes only draw from the palette source of the Env, so that
+// they can be replayed.
+func (e *Env) GenColors() {
+	ps := e.config.Color.Palettes
+	r := paletteRanges[ps[e.paletteRand.Intn(len(ps))]]
+
+	cs := ColorScheme{
+		color.Black,
+	}
+
+	h0 := e.paletteRand.Float64() * 360.0
+	for i := 1; i <= LiveCellN; i++ {
+		h := math.Mod(h0+float64(i-1)*360.0/float64(LiveCellN), 360.0)
+		var c colorful.Color
+		for try := 0; try < paletteTries; try++ {
+			c = colorful.Hcl(h, e.between(r.chroma), e.between(r.light))
+			if c.IsValid() {
+				break
+			}
+		}
+		cs[i] = c.Clamped()
+	}
+
+	SetColorScheme(cs)
+}
+
+// between returns a number drawn uniformly from the palette source within
+// the bounds of r.
+func (e *Env) between(r [2]float64) float64 {
+	return r[0] + e.paletteRand.Float64()*(r[1]-r[0])
+}
+
+// parseColors parses hex colors.
+func parseColors(hs []string) ([]color.Color, error) {
+	cs := make([]color.Color, len(hs))
+
+	for i, h := range hs {
+		c, err := colorful.Hex(strings.TrimSpace(h))
+		if err != nil {
+			return nil, fmt.Errorf("%s (%v)", h, err)
+		}
+		cs[i] = c
+	}
+
+	return cs, nil
+}
+
+// ParseColorScheme makes a color scheme of the hex colors of live cells.
+func ParseColorScheme(hs []string) (ColorScheme, error) {
+	cs := ColorScheme{
+		color.Black,
+	}
+
+	if len(hs) != LiveCellN {
+		return cs, fmt.Errorf("%d colors; must be %d", len(hs), LiveCellN)
+	}
+
+	colors, err := parseColors(hs)
+	if err != nil {
+		return cs, err
+	}
+	copy(cs[1:], colors)
+
+	return cs, nil
+}
+
+// gradient returns n colors evenly spaced along a gradient through cs,
+// blended in the Lab space. Closed gradients wrap around from the last
+// color back to the first.
+func gradient(cs []color.Color, n int, closed bool) []color.Color {
+	stops := make([]colorful.Color, len(cs))
+	for i, c := range cs {
+		stops[i], _ = colorful.MakeColor(c)
+	}
+
+	segments := len(stops)
+	if !closed {
+		segments--
+	}
+
+	g := make([]color.Color, n)
+	for i := range g {
+		t := 0.0
+		if closed {
+			t = float64(i) / float64(n) * float64(segments)
+		} else if n > 1 {
+			t = float64(i) / float64(n-1) * float64(segments)
+		}
+		k := int(t)
+		if k >= segments {
+			g[i] = stops[len(stops)-1]
+			continue
+		}
+		g[i] = stops[k].BlendLab(stops[(k+1)%len(stops)], t-float64(k)).
+			Clamped()
+	}
+
+	return g
+}
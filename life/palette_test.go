@@ -0,0 +1,62 @@
+package life
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestGenColors(t *testing.T) {
+	defer SetColorScheme(colorScheme)
+
+	var schemes [2]ColorScheme
+	for i := range schemes {
+		e := newTestEnv(t, testWidth, testHeight, func(c *Config) {
+			c.Seed = 7
+		})
+		e.GenColors()
+		schemes[i] = colorScheme
+	}
+
+	if schemes[0] != schemes[1] {
+		t.Errorf("color scheme = %v; want %v", schemes[1], schemes[0])
+	}
+}
+
+func TestParseColorScheme(t *testing.T) {
+	cs, err := ParseColorScheme([]string{"#ff0000", "#00ff00", "#0000ff",
+		"#ffffff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := cs[2].RGBA(); r != 0 || g != 0xffff || b != 0 {
+		t.Errorf("color 2 = %v; want #00ff00", cs[2])
+	}
+
+	for _, hs := range [...][]string{{"#ff0000"}, {"red", "#00ff00",
+		"#0000ff", "#ffffff"}} {
+		if _, err := ParseColorScheme(hs); err == nil {
+			t.Errorf("ParseColorScheme(%v) succeeded", hs)
+		}
+	}
+}
+
+func TestGradient(t *testing.T) {
+	cs := []color.Color{color.Black, color.White}
+
+	g := gradient(cs, 3, false)
+	for i, want := range [...]uint32{0, 0x7777, 0xffff} {
+		r, _, _, _ := g[i].RGBA()
+		if d := int(r) - int(want); d < -0x1000 || d > 0x1000 {
+			t.Errorf("open color %d = %v; want ~%#x", i, g[i], want)
+		}
+	}
+
+	// Closed gradients go back to black after white.
+	g = gradient(cs, 4, true)
+	r0, _, _, _ := g[0].RGBA()
+	r1, _, _, _ := g[1].RGBA()
+	r3, _, _, _ := g[3].RGBA()
+	if r0 != 0 || r1 != r3 {
+		t.Errorf("closed colors = %v; want black, c, white, c", g)
+	}
+}
@@ -0,0 +1,177 @@
+package life
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"lifelight/pattern"
+)
+
+func isDigits(str string) bool {
+	for _, d := range str {
+		if d < '0' || d > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePatternRule parses the rule of a pattern file, also accepting the
+// S/B and S/B/C notations of older files and Golly, and ignoring Golly's
+// bounded grid suffixes.
+func parsePatternRule(str string) (rule, error) {
+	if i := strings.IndexByte(str, ':'); i >= 0 {
+		str = str[:i]
+	}
+	ps := strings.Split(str, "/")
+	for _, p := range ps {
+		if !isDigits(p) {
+			return parseRule(str)
+		}
+	}
+	switch len(ps) {
+	case 2:
+		str = "B" + ps[1] + "/S" + ps[0]
+	case 3:
+		str = "B" + ps[1] + "/S" + ps[0] + "/C" + ps[2]
+	}
+	return parseRule(str)
+}
+
+// exported reports whether a pattern was exported by lifelight, its cells
+// holding states along with their colors rather than Golly's states.
+func exported(p *pattern.Pattern) bool {
+	return p.Name == exportName
+}
+
+// checkPattern ensures a pattern runs under the configured rule. Patterns
+// exported by lifelight hold cell states, with their colors. Patterns of
+// automata other than life and wireworld are not checked.
+func (c *Config) checkPattern(p *pattern.Pattern) error {
+	switch c.Automaton {
+	case "life":
+	case "wireworld":
+		return checkWireworldPattern(p)
+	default:
+		return nil
+	}
+	if p.Rule != "" {
+		r, err := parsePatternRule(p.Rule)
+		if err != nil {
+			return fmt.Errorf("rule = %s; %v", p.Rule, err)
+		}
+		if r.String() != c.rule.String() {
+			return fmt.Errorf("rule = %s; does not match Rule = %s",
+				p.Rule, c.rule)
+		}
+	}
+	max := c.rule.states - 1
+	if exported(p) {
+		max *= LiveCellN
+	}
+	if p.States()-1 > max {
+		return fmt.Errorf("states = %d; must be <= %d", p.States(), max+1)
+	}
+	return nil
+}
+
+func (c *Config) loadPattern(path string) (*pattern.Pattern, error) {
+	p, err := pattern.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.checkPattern(p); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return p, nil
+}
+
+// loadPatternDir loads the patterns of PatternDir, skipping invalid ones.
+func (c *Config) loadPatternDir() error {
+	fs, err := ioutil.ReadDir(c.PatternDir)
+	if err != nil {
+		return err
+	}
+
+	c.patterns = nil
+	for _, f := range fs {
+		if f.IsDir() {
+			continue
+		}
+		p, err := c.loadPattern(filepath.Join(c.PatternDir, f.Name()))
+		if err != nil {
+			log.Printf("config: Skipping pattern: %v\n", err)
+			continue
+		}
+		c.patterns = append(c.patterns, p)
+		logger.log("config", "Loaded pattern '%s'\n", p.Name)
+	}
+
+	return nil
+}
+
+// patternOffsets returns the positions of the live cells of a pattern.
+func patternOffsets(p *pattern.Pattern) []offset {
+	var os []offset
+
+	for y := 0; y < p.Height; y++ {
+		for x := 0; x < p.Width; x++ {
+			if isLive(p.At(x, y)) {
+				os = append(os, offset{x, y})
+			}
+		}
+	}
+
+	return os
+}
+
+// LoadPattern sets the world to a pattern, centered and cropped to fit.
+// Cells of life patterns in Golly's states, live or dying, get random colors
+// unless the pattern was exported by lifelight, and cells in states the
+// automaton lacks are left empty.
+func (e *Env) LoadPattern(p *pattern.Pattern) {
+	x0, y0 := (e.width-p.Width)/2, (e.height-p.Height)/2
+	_, life := e.automaton.(lifeAutomaton)
+	colors := life && !exported(p)
+
+	for i := range e.cells {
+		x, y := getCoords(i, e.width)
+		x, y = x-x0, y-y0
+		e.cells[i] = cellDead
+		if x < 0 || x >= p.Width || y < 0 || y >= p.Height {
+			continue
+		}
+		c := p.At(x, y)
+		if colors && c != cellDead {
+			// Golly's state k is the dying stage k-1 of live cells.
+			c = (c-1)*LiveCellN + e.randomLiveCell()
+		}
+		if c < e.automaton.States() {
+			e.cells[i] = c
+		}
+	}
+
+	e.load()
+}
+
+// Reset sets the world to the configured pattern, to one of the pattern
+// directory if Pattern is random, or to random cells.
+func (e *Env) Reset() {
+	c := e.config
+	p := c.initial
+	if c.Pattern == "random" {
+		p = c.patterns[e.rand.Intn(len(c.patterns))]
+	}
+
+	if p == nil {
+		e.event("randomize")
+		e.Randomize()
+		return
+	}
+	logger.log("seed", "pattern = %s\n", p.Name)
+	e.event("pattern %s", p.Name)
+	e.LoadPattern(p)
+}
@@ -0,0 +1,117 @@
+package life
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lifelight/pattern"
+)
+
+func TestParsePatternRule(t *testing.T) {
+	rules := map[string]string{
+		"23/3":          "B3/S23",
+		"B3/S23:T64,64": "B3/S23",
+		"b36/s23":       "B36/S23",
+		"B2/S/C3":       "B2/S/C3",
+		"345/2/4":       "B2/S345/C4",
+		"/2/3":          "B2/S/C3",
+	}
+
+	for str, want := range rules {
+		r, err := parsePatternRule(str)
+		if err != nil {
+			t.Errorf("parsePatternRule(%s): %v", str, err)
+			continue
+		}
+		if r.String() != want {
+			t.Errorf("parsePatternRule(%s) = %s; want %s", str, r, want)
+		}
+	}
+}
+
+func TestCheckPattern(t *testing.T) {
+	c := NewConfig()
+	ps := map[string]bool{
+		"x = 1, y = 1, rule = 23/3\no!":    true,
+		"#N lifelight\nx = 1, y = 1\nD!":   true,
+		"x = 1, y = 1, rule = B36/S23\no!": false,
+		"#N lifelight\nx = 1, y = 1\nE!":   false,
+		"x = 1, y = 1\nB!":                 false,
+	}
+
+	for str, ok := range ps {
+		p, err := pattern.ReadRLE(strings.NewReader(str))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = c.checkPattern(p); (err == nil) != ok {
+			t.Errorf("checkPattern(%q) = %v", str, err)
+		}
+	}
+}
+
+func TestLoadPattern(t *testing.T) {
+	p, _ := pattern.ReadRLE(strings.NewReader("x = 3, y = 3\nbo$2bo$3o!"))
+	e := newTestEnv(t, 7, 7, withRule("B3/S23"))
+	e.LoadPattern(p)
+
+	for i, c := range e.cells {
+		x, y := getCoords(i, e.width)
+		live := x >= 2 && x < 5 && y >= 2 && y < 5 && p.At(x-2, y-2) == 1
+		if isLive(c) != live {
+			t.Errorf("cell (%d, %d) = %d", x, y, c)
+		}
+	}
+}
+
+func TestLoadGenerationsPattern(t *testing.T) {
+	p, err := pattern.ReadRLE(strings.NewReader(
+		"x = 3, y = 1, rule = B2/S/C3\nABA!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newTestEnv(t, 3, 1, withRule("B2/S/C3"))
+	if err = e.config.checkPattern(p); err != nil {
+		t.Fatal(err)
+	}
+	e.LoadPattern(p)
+
+	for i, stage := range [...]int{0, 1, 0} {
+		if c := e.cells[i]; c == cellDead || cellStage(c) != stage {
+			t.Errorf("cell[%d] = %d; want stage %d", i, c, stage)
+		}
+	}
+}
+
+func TestLoadPatternDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lifelight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"glider.rle":    "x = 3, y = 3, rule = B3/S23\nbo$2bo$3o!",
+		"glider.cells":  ".O\n..O\nOOO",
+		"highlife.rle":  "x = 3, y = 3, rule = B36/S23\nbo$2bo$3o!",
+		"invalid.cells": ".O\n..X",
+	}
+	for name, str := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(str), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewConfig()
+	c.PatternDir = dir
+	if err := c.loadPatternDir(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.patterns); n != 2 {
+		t.Errorf("patterns = %d; want 2", n)
+	}
+}
@@ -0,0 +1,372 @@
+package life
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Recordings of matrices larger than this are rejected.
+const maxRecordSize = 1 << 12
+
+// Recordings are gzipped streams starting with a header, followed by
+// records of one tag byte each:
+//
+//	header:   "LLRC" version width height rule seed ticks-per-second
+//	K:        keyframe, the state of every cell
+//	D:        delta frame, the number of changed cells, then the distance
+//	          from the previous changed cell and the state of each
+//	C:        color scheme, RGBA bytes of every color
+//	E:        event, e.g. seeding, as text
+//
+// Numbers are unsigned varints, except the seed which is a signed varint,
+// and strings are prefixed with their length. Colors of the truecolor and
+// age modes are not recorded.
+const (
+	recordMagic   = "LLRC"
+	recordVersion = 1
+)
+
+const (
+	recordKeyframe = 'K'
+	recordDelta    = 'D'
+	recordColors   = 'C'
+	recordEvent    = 'E'
+)
+
+// A Recorder writes the generations of an Env to a recording.
+type Recorder struct {
+	f         io.Closer
+	gz        *gzip.Writer
+	w         *bufio.Writer
+	prev      Cells
+	scheme    ColorScheme
+	frames    int
+	keyframes int
+	buf       [binary.MaxVarintLen64]byte
+}
+
+func (rc *Recorder) uvarint(n uint64) {
+	rc.w.Write(rc.buf[:binary.PutUvarint(rc.buf[:], n)])
+}
+
+func (rc *Recorder) string(s string) {
+	rc.uvarint(uint64(len(s)))
+	rc.w.WriteString(s)
+}
+
+// NewRecorder starts a recording of e to w, which is closed along with the
+// Recorder if it is an io.Closer.
+func NewRecorder(e *Env, w io.Writer, keyframes int) (*Recorder, error) {
+	if keyframes < 1 {
+		keyframes = 1
+	}
+	gz := gzip.NewWriter(w)
+	rc := &Recorder{
+		gz:        gz,
+		w:         bufio.NewWriter(gz),
+		prev:      make(Cells, e.size),
+		keyframes: keyframes,
+	}
+	if c, ok := w.(io.Closer); ok {
+		rc.f = c
+	}
+
+	c := e.config
+	rc.w.WriteString(recordMagic)
+	rc.uvarint(recordVersion)
+	rc.uvarint(uint64(c.Hardware.MatrixWidth))
+	rc.uvarint(uint64(c.Hardware.MatrixHeight))
+	rc.string(e.automaton.String())
+	rc.w.Write(rc.buf[:binary.PutVarint(rc.buf[:], e.randSeed)])
+	rc.uvarint(uint64(c.TicksPerSecond))
+
+	e.recorder = rc
+	rc.frame(e)
+
+	return rc, rc.w.Flush()
+}
+
+// Record starts a recording of e in Record.Dir, named after the current
+// time.
+func (e *Env) Record() (*Recorder, string, error) {
+	c := e.config.Record
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, "", err
+	}
+
+	path := filepath.Join(c.Dir,
+		"lifelight-"+time.Now().Format("20060102-150405")+".rec.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rc, err := NewRecorder(e, f, c.Keyframes)
+	if err != nil {
+		f.Close()
+		return nil, "", err
+	}
+	return rc, path, nil
+}
+
+// frame records the current generation of e, as a keyframe every
+// keyframes frames or as the changes since the previous one otherwise.
+func (rc *Recorder) frame(e *Env) {
+	if colorScheme != rc.scheme {
+		rc.w.WriteByte(recordColors)
+		for _, c := range colorScheme {
+			rgba := toRGBA(c)
+			rc.w.Write([]byte{rgba.R, rgba.G, rgba.B, rgba.A})
+		}
+		rc.scheme = colorScheme
+	}
+
+	if rc.frames%rc.keyframes == 0 {
+		rc.w.WriteByte(recordKeyframe)
+		for _, c := range e.cells {
+			rc.uvarint(uint64(c))
+		}
+		// Keyframes are where replays can start from, so they are not
+		// left in buffers.
+		rc.w.Flush()
+		rc.gz.Flush()
+	} else {
+		n := 0
+		for i, c := range e.cells {
+			if c != rc.prev[i] {
+				n++
+			}
+		}
+		rc.w.WriteByte(recordDelta)
+		rc.uvarint(uint64(n))
+		last := 0
+		for i, c := range e.cells {
+			if c != rc.prev[i] {
+				rc.uvarint(uint64(i - last))
+				rc.uvarint(uint64(c))
+				last = i
+			}
+		}
+	}
+
+	copy(rc.prev, e.cells)
+	rc.frames++
+}
+
+func (rc *Recorder) event(str string) {
+	rc.w.WriteByte(recordEvent)
+	rc.string(str)
+}
+
+// Close ends the recording.
+func (rc *Recorder) Close() error {
+	err := rc.w.Flush()
+	if gerr := rc.gz.Close(); err == nil {
+		err = gerr
+	}
+	if rc.f != nil {
+		if ferr := rc.f.Close(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// event records something happening to the Env, if it is being recorded.
+func (e *Env) event(format string, v ...interface{}) {
+	if e.recorder != nil {
+		e.recorder.event(fmt.Sprintf(format, v...))
+	}
+}
+
+// A Player plays a recording back.
+type Player struct {
+	r      *bufio.Reader
+	env    *Env
+	seed   int64
+	tps    int
+	frames int
+	Events []string
+}
+
+func (p *Player) uvarint() (int, error) {
+	n, err := binary.ReadUvarint(p.r)
+	return int(n), err
+}
+
+// cell reads the state of a cell, which must have a color.
+func (p *Player) cell() (int, error) {
+	c, err := p.uvarint()
+	if err == nil && c >= p.env.automaton.States() {
+		err = fmt.Errorf("invalid state %d", c)
+	}
+	return c, err
+}
+
+func (p *Player) string() (string, error) {
+	n, err := p.uvarint()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(p.r, b)
+	return string(b), err
+}
+
+// NewPlayer reads the header of a recording, made with the automaton of c.
+// Recordings of life rules other than the configured one can be played
+// too.
+func NewPlayer(c *Config, r io.Reader) (*Player, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &Player{r: bufio.NewReader(gz)}
+
+	magic := make([]byte, len(recordMagic))
+	if _, err = io.ReadFull(p.r, magic); err != nil ||
+		string(magic) != recordMagic {
+		return nil, fmt.Errorf("not a recording")
+	}
+	if v, err := p.uvarint(); err != nil || v != recordVersion {
+		return nil, fmt.Errorf("unsupported version %d", v)
+	}
+
+	pc := *c
+	var hs [2]int
+	for i := range hs {
+		if hs[i], err = p.uvarint(); err != nil {
+			return nil, err
+		}
+		if hs[i] < 1 || hs[i] > maxRecordSize {
+			return nil, fmt.Errorf("invalid size %d", hs[i])
+		}
+	}
+	pc.Hardware.MatrixWidth, pc.Hardware.MatrixHeight = hs[0], hs[1]
+	rule, err := p.string()
+	if err != nil {
+		return nil, err
+	}
+	if pc.Automaton == "life" {
+		if pc.rule, err = parseRule(rule); err != nil {
+			return nil, fmt.Errorf("rule = %s; %v", rule, err)
+		}
+		pc.Rule = rule
+	}
+	if p.seed, err = binary.ReadVarint(p.r); err != nil {
+		return nil, err
+	}
+	if p.tps, err = p.uvarint(); err != nil {
+		return nil, err
+	}
+	pc.Seed = p.seed
+	pc.Engine = "table"
+	pc.Color.Mode = "palette"
+	pc.Stagnation.MaxPeriod = 0
+	pc.History.Generations = 0
+	pc.Workers = 1
+	// Ants are not recorded, so the cells they are on are unknown.
+	pc.turmiteHighlight = nil
+
+	p.env = NewEnv(&pc)
+	if s := p.env.automaton.String(); s != rule {
+		return nil, fmt.Errorf("rule = %s; does not match %s", rule, s)
+	}
+
+	return p, nil
+}
+
+// Seed returns the seed of the recorded session.
+func (p *Player) Seed() int64 {
+	return p.seed
+}
+
+// Size returns the size of the recorded matrix.
+func (p *Player) Size() (int, int) {
+	h := p.env.config.Hardware
+	return h.MatrixWidth, h.MatrixHeight
+}
+
+func (p *Player) TicksPerSecond() int {
+	return p.tps
+}
+
+// Frames returns the number of frames played so far.
+func (p *Player) Frames() int {
+	return p.frames
+}
+
+// Next reads the next frame, along with the events leading to it. It
+// returns io.EOF at the end of the recording.
+func (p *Player) Next() error {
+	p.Events = p.Events[:0]
+	cells := p.env.cells
+
+	for {
+		tag, err := p.r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		switch tag {
+		case recordKeyframe:
+			for i := range cells {
+				if cells[i], err = p.cell(); err != nil {
+					return err
+				}
+			}
+			p.frames++
+			return nil
+		case recordDelta:
+			n, err := p.uvarint()
+			if err != nil {
+				return err
+			}
+			i := 0
+			for ; n > 0; n-- {
+				d, err := p.uvarint()
+				if err != nil {
+					return err
+				}
+				if i += d; i >= len(cells) {
+					return fmt.Errorf("cell %d out of range", i)
+				}
+				if cells[i], err = p.cell(); err != nil {
+					return err
+				}
+			}
+			p.frames++
+			return nil
+		case recordColors:
+			var cs ColorScheme
+			var b [4]byte
+			for i := range cs {
+				if _, err = io.ReadFull(p.r, b[:]); err != nil {
+					return err
+				}
+				cs[i] = color.RGBA{b[0], b[1], b[2], b[3]}
+			}
+			SetColorScheme(cs)
+		case recordEvent:
+			s, err := p.string()
+			if err != nil {
+				return err
+			}
+			p.Events = append(p.Events, s)
+		default:
+			return fmt.Errorf("invalid record '%c'", tag)
+		}
+	}
+}
+
+// Draw renders the current frame.
+func (p *Player) Draw(r Renderer) {
+	p.env.Draw(r)
+}
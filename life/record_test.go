@@ -0,0 +1,67 @@
+package life
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	defer SetColorScheme(colorScheme)
+
+	e := newTestEnv(t, 16, 8, withRule("B2/S/C3"), func(c *Config) {
+		c.Seed = 3
+	})
+	e.seedCooldownTicks = 0
+
+	var b bytes.Buffer
+	rc, err := NewRecorder(e, &b, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []Cells{append(Cells{}, e.cells...)}
+	for g := 0; g < 30; g++ {
+		e.tick()
+		frames = append(frames, append(Cells{}, e.cells...))
+	}
+	e.event("done")
+	if err := rc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPlayer(NewConfig(), &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := p.Size(); w != 16 || h != 8 || p.Seed() != 3 {
+		t.Errorf("size = %dx%d, seed = %d; want 16x8, 3", w, h, p.Seed())
+	}
+	if p.env.rule.String() != "B2/S/C3" {
+		t.Errorf("rule = %s; want B2/S/C3", p.env.rule)
+	}
+
+	for i, want := range frames {
+		if err := p.Next(); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		for j, c := range p.env.cells {
+			if c != want[j] {
+				t.Fatalf("frame %d: cell[%d] = %d; want %d", i, j, c,
+					want[j])
+			}
+		}
+	}
+	if err := p.Next(); err != io.EOF {
+		t.Errorf("Next = %v; want EOF", err)
+	}
+	if len(p.Events) != 1 || p.Events[0] != "done" {
+		t.Errorf("events = %v; want [done]", p.Events)
+	}
+}
+
+func TestPlayerInvalid(t *testing.T) {
+	_, err := NewPlayer(NewConfig(), bytes.NewReader([]byte("LLRC")))
+	if err == nil {
+		t.Error("NewPlayer succeeded without gzip")
+	}
+}
@@ -0,0 +1,300 @@
+package life
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const maxStates = 256
+
+type rule struct {
+	birth        []bool
+	survival     []bool
+	states       int
+	radius       int
+	neighborhood neighborhood
+	middle       bool
+	ltl          bool
+}
+
+var neighborhoodCodes = map[string]neighborhood{
+	"M": moore,
+	"N": vonNeumann,
+	"C": circular,
+	"H": hexagonal,
+}
+
+var neighborhoodSuffixes = map[string]neighborhood{
+	"H": hexagonal,
+	"V": vonNeumann,
+}
+
+func parseCounts(str string, counts []bool) error {
+	for _, d := range str {
+		if d < '0' || int(d-'0') >= len(counts) {
+			return fmt.Errorf("invalid neighbor count '%c'", d)
+		}
+		counts[d-'0'] = true
+	}
+	return nil
+}
+
+func parseInt(str string, min, max int) (int, error) {
+	n, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s'", str)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("%d must be in range [%d, %d]", n, min, max)
+	}
+	return n, nil
+}
+
+func parseRange(str string, counts []bool) error {
+	bounds := strings.Split(str, "..")
+	if len(bounds) > 2 {
+		return fmt.Errorf("invalid range '%s'", str)
+	}
+
+	max := len(counts) - 1
+	lo, err := parseInt(bounds[0], 0, max)
+	if err != nil {
+		return err
+	}
+	hi := lo
+	if len(bounds) == 2 {
+		if hi, err = parseInt(bounds[1], lo, max); err != nil {
+			return err
+		}
+	}
+
+	for i := lo; i <= hi; i++ {
+		counts[i] = true
+	}
+	return nil
+}
+
+func formatRange(counts []bool) string {
+	lo, hi := -1, -1
+	for i, ok := range counts {
+		if ok {
+			if lo < 0 {
+				lo = i
+			}
+			hi = i
+		}
+	}
+	return fmt.Sprintf("%d..%d", lo, hi)
+}
+
+// parseLtL parses a Larger than Life rule in Golly's notation, e.g.
+// R5,C0,M1,S34..58,B34..45,NM.
+func parseLtL(str string) (r rule, err error) {
+	r.states = 2
+	r.radius = 1
+	r.ltl = true
+
+	keys := make(map[byte]string)
+
+	for _, p := range strings.Split(str, ",") {
+		if p == "" {
+			return r, fmt.Errorf("empty field")
+		}
+		if _, ok := keys[p[0]]; ok {
+			return r, fmt.Errorf("duplicate field '%c'", p[0])
+		}
+		keys[p[0]] = p[1:]
+	}
+
+	for k, v := range keys {
+		switch k {
+		case 'R':
+			r.radius, err = parseInt(v, 1, maxRadius)
+		case 'C':
+			if r.states, err = parseInt(v, 0, maxStates); r.states < 2 {
+				r.states = 2
+			}
+		case 'M':
+			var m int
+			m, err = parseInt(v, 0, 1)
+			r.middle = m == 1
+		case 'N':
+			nh, ok := neighborhoodCodes[v]
+			if !ok {
+				err = fmt.Errorf("invalid neighborhood '%s'", v)
+			}
+			r.neighborhood = nh
+		case 'B', 'S':
+		default:
+			err = fmt.Errorf("unexpected field '%c'", k)
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+
+	n := len(r.offsets()[0]) + 1
+	if r.middle {
+		n++
+	}
+	r.birth = make([]bool, n)
+	r.survival = make([]bool, n)
+
+	for i, counts := range [...][]bool{r.birth, r.survival} {
+		k := "BS"[i]
+		v, ok := keys[k]
+		if !ok {
+			return r, fmt.Errorf("missing field '%c'", k)
+		}
+		if err = parseRange(v, counts); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+func parseStates(str string) (int, error) {
+	var n int
+	if _, err := fmt.Sscanf(str, "%d", &n); err != nil ||
+		fmt.Sprint(n) != str {
+		return 0, fmt.Errorf("invalid state count '%s'", str)
+	}
+	if n < 2 || n > maxStates {
+		return 0, fmt.Errorf("state count = %d; must be in range [2, %d]",
+			n, maxStates)
+	}
+	return n, nil
+}
+
+func parseRule(str string) (r rule, err error) {
+	str = strings.ToUpper(str)
+	if strings.HasPrefix(str, "R") {
+		return parseLtL(str)
+	}
+
+	r.states = 2
+	r.radius = 1
+
+	if n := len(str); n > 0 {
+		if nh, ok := neighborhoodSuffixes[str[n-1:]]; ok {
+			str = str[:n-1]
+			r.neighborhood = nh
+		}
+	}
+
+	r.birth = make([]bool, len(r.offsets()[0])+1)
+	r.survival = make([]bool, len(r.birth))
+
+	parts := strings.Split(str, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return r, fmt.Errorf("must be in B/S or B/S/C notation")
+	}
+
+	var hasB, hasS, hasC bool
+
+	for _, p := range parts {
+		switch {
+		case strings.HasPrefix(p, "B") && !hasB:
+			err = parseCounts(p[1:], r.birth)
+			hasB = true
+		case strings.HasPrefix(p, "S") && !hasS:
+			err = parseCounts(p[1:], r.survival)
+			hasS = true
+		case strings.HasPrefix(p, "C") && !hasC:
+			r.states, err = parseStates(p[1:])
+			hasC = true
+		default:
+			err = fmt.Errorf("unexpected '%s'", p)
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+
+	if !hasB || !hasS {
+		return r, fmt.Errorf("must be in B/S or B/S/C notation")
+	}
+
+	return r, nil
+}
+
+func (r *rule) offsets() [2][]offset {
+	return r.neighborhood.offsets(r.radius)
+}
+
+func (r rule) String() string {
+	if r.ltl {
+		nh := "M"
+		for code, n := range neighborhoodCodes {
+			if n == r.neighborhood {
+				nh = code
+			}
+		}
+		states, middle := 0, 0
+		if r.states > 2 {
+			states = r.states
+		}
+		if r.middle {
+			middle = 1
+		}
+		return fmt.Sprintf("R%d,C%d,M%d,S%s,B%s,N%s", r.radius, states,
+			middle, formatRange(r.survival), formatRange(r.birth), nh)
+	}
+
+	var b, s strings.Builder
+
+	for i := range r.birth {
+		if r.birth[i] {
+			fmt.Fprint(&b, i)
+		}
+		if r.survival[i] {
+			fmt.Fprint(&s, i)
+		}
+	}
+
+	str := fmt.Sprintf("B%s/S%s", b.String(), s.String())
+	if r.states > 2 {
+		str += fmt.Sprintf("/C%d", r.states)
+	}
+	for suffix, nh := range neighborhoodSuffixes {
+		if nh == r.neighborhood {
+			str += suffix
+		}
+	}
+
+	return str
+}
+
+func (r *rule) apply(c, n int, cs [LiveCellN]int, inherit inheritFunc,
+	rnd *rand.Rand) int {
+	if r.middle && isLive(c) {
+		n++
+	}
+
+	if c == cellDead {
+		if r.birth[n] {
+			return inherit(cs, rnd)
+		}
+		return cellDead
+	}
+
+	if isLive(c) && r.survival[n] {
+		return c
+	}
+
+	return r.decay(c)
+}
+
+// decay moves a cell that failed to survive into its next dying state,
+// keeping its color, or kills it after the last dying state.
+func (r *rule) decay(c int) int {
+	s := cellStage(c) + 1
+	if s >= r.states-1 {
+		return cellDead
+	}
+
+	return s*LiveCellN + cellColor(c)
+}
@@ -0,0 +1,114 @@
+package life
+
+import (
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	rules := map[string]string{
+		"B3/S23":       "B3/S23",
+		"b36/s23":      "B36/S23",
+		"S34678/B3678": "B3678/S34678",
+		"B2/S":         "B2/S",
+		"B2/S/C3":      "B2/S/C3",
+		"C4/S345/B2":   "B2/S345/C4",
+		"B3/S23/C2":    "B3/S23",
+		"B2/S34H":      "B2/S34H",
+		"B2/S013v":     "B2/S013V",
+		"B2/S/C3H":     "B2/S/C3H",
+
+		"R5,C0,M1,S34..58,B34..45,NM": "R5,C0,M1,S34..58,B34..45,NM",
+		"r2,b3..4,s2..5,nn":           "R2,C0,M0,S2..5,B3..4,NN",
+		"R1,C3,M0,S2,B3,NC":           "R1,C3,M0,S2..2,B3..3,NC",
+	}
+
+	for str, want := range rules {
+		r, err := parseRule(str)
+		if err != nil {
+			t.Errorf("parseRule(%s): %v", str, err)
+			continue
+		}
+		if r.String() != want {
+			t.Errorf("parseRule(%s) = %s; want %s", str, r, want)
+		}
+	}
+}
+
+func TestParseRuleInvalid(t *testing.T) {
+	for _, str := range [...]string{"", "B3", "B39/S23", "B3/S2/S3", "B3/B23", "3/23",
+		"B2/S/C1", "B2/S/C300", "B2/S/Cx", "B2/C3",
+		"R11,S1,B1", "R2,S1..30,B1", "R2,S5..3,B1", "R2,S1", "R2,,S1,B1",
+		"R2,S1,B1,NX", "R2,S1,B1,R3", "R2,S1,B1,X1",
+		"B2/S7H", "B5/S2V", "B2/S3X"} {
+		if _, err := parseRule(str); err == nil {
+			t.Errorf("parseRule(%s) = nil; want error", str)
+		}
+	}
+}
+
+func TestApplyRulesHighLife(t *testing.T) {
+	r, _ := parseRule("B36/S23")
+
+	if c := r.apply(cellDead, 6, [LiveCellN]int{3, 3, 0, 0}, inheritMajority, nil); c != cellLive3 {
+		t.Errorf("birth = %d; want %d", c, cellLive3)
+	}
+	if c := r.apply(cellLive2, 6, [LiveCellN]int{}, inheritMajority, nil); c != cellDead {
+		t.Errorf("survival = %d; want %d", c, cellDead)
+	}
+	if c := conway.apply(cellDead, 6, [LiveCellN]int{3, 3, 0, 0}, inheritMajority, nil); c != cellDead {
+		t.Errorf("birth = %d; want %d", c, cellDead)
+	}
+}
+
+func TestApplyRulesGenerations(t *testing.T) {
+	r, _ := parseRule("B2/S/C4")
+
+	states := [...]int{cellLive2, cellLive2 + LiveCellN, cellLive2 + 2*LiveCellN}
+	want := [...]int{cellLive2 + LiveCellN, cellLive2 + 2*LiveCellN, cellDead}
+
+	for i, c := range states {
+		if n := r.apply(c, 2, [LiveCellN]int{}, inheritMajority, nil); n != want[i] {
+			t.Errorf("apply(%d) = %d; want %d", c, n, want[i])
+		}
+	}
+
+	if isLive(states[1]) {
+		t.Errorf("isLive(%d) = true; want false", states[1])
+	}
+	if c := cellColor(states[2]); c != cellLive2 {
+		t.Errorf("cellColor(%d) = %d; want %d", states[2], c, cellLive2)
+	}
+}
+
+func TestGetContextGenerations(t *testing.T) {
+	cells := Cells{
+		0, 0, 0,
+		0, 0, cellLive1 + LiveCellN,
+		cellLive3, 0, 0,
+	}
+	ns := getNeighbors(4, 3, 3, conway.offsets(), wrapTorus)
+
+	if n, _ := getContext(cells, ns); n != 1 {
+		t.Errorf("n = %d; want 1", n)
+	}
+}
+
+func TestApplyRulesLtL(t *testing.T) {
+	r, _ := parseRule("R5,C0,M1,S34..58,B34..45,NM")
+
+	if n := len(r.birth); n != 122 {
+		t.Errorf("len(birth) = %d; want 122", n)
+	}
+	if c := r.apply(cellDead, 34, [LiveCellN]int{30, 4, 0, 0}, inheritMajority, nil); c != cellLive1 {
+		t.Errorf("birth = %d; want %d", c, cellLive1)
+	}
+	if c := r.apply(cellDead, 46, [LiveCellN]int{46, 0, 0, 0}, inheritMajority, nil); c != cellDead {
+		t.Errorf("birth = %d; want %d", c, cellDead)
+	}
+	if c := r.apply(cellLive2, 33, [LiveCellN]int{}, inheritMajority, nil); c != cellLive2 {
+		t.Errorf("survival = %d; want %d", c, cellLive2)
+	}
+	if c := r.apply(cellLive2, 58, [LiveCellN]int{}, inheritMajority, nil); c != cellDead {
+		t.Errorf("survival = %d; want %d", c, cellDead)
+	}
+}
@@ -0,0 +1,225 @@
+package life
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Seeder adds cells to the next generation of an Env once its dead zones
+// reach the seed threshold.
+type Seeder interface {
+	Seed(e *Env)
+}
+
+// SeederFunc adapts a function to the Seeder interface.
+type SeederFunc func(e *Env)
+
+func (f SeederFunc) Seed(e *Env) {
+	f(e)
+}
+
+var seeders = map[string]Seeder{
+	"deadzone":  SeederFunc(seedDeadZone),
+	"spaceship": SeederFunc(seedSpaceship),
+	"symmetric": SeederFunc(seedSymmetric),
+	"rain":      SeederFunc(seedRain),
+	"library":   SeederFunc(seedLibrary),
+}
+
+// RegisterSeeder makes a Seeder available to the Seeders key under name.
+func RegisterSeeder(name string, s Seeder) {
+	seeders[name] = s
+}
+
+type weightedSeeder struct {
+	name   string
+	seeder Seeder
+	weight int
+}
+
+// parseSeeders parses seeders given as name[:weight], the weight defaulting
+// to 1.
+func parseSeeders(strs []string) ([]weightedSeeder, error) {
+	ws := make([]weightedSeeder, 0, len(strs))
+
+	for _, str := range strs {
+		str = strings.TrimSpace(str)
+		name, weight := str, 1
+
+		if i := strings.IndexByte(str, ':'); i >= 0 {
+			name = str[:i]
+			w, err := strconv.Atoi(str[i+1:])
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid weight '%s'", str[i+1:])
+			}
+			weight = w
+		}
+
+		s, ok := seeders[name]
+		if !ok {
+			names := make([]string, 0, len(seeders))
+			for n := range seeders {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("'%s' must be one of: %s", name,
+				strings.Join(names, ", "))
+		}
+		ws = append(ws, weightedSeeder{name, s, weight})
+	}
+
+	if len(ws) == 0 {
+		return nil, fmt.Errorf("no seeder")
+	}
+
+	return ws, nil
+}
+
+func pickSeeder(ws []weightedSeeder, r *rand.Rand) weightedSeeder {
+	total := 0
+	for _, w := range ws {
+		total += w.weight
+	}
+
+	n := r.Intn(total)
+	for _, w := range ws {
+		if n < w.weight {
+			return w
+		}
+		n -= w.weight
+	}
+	return ws[len(ws)-1]
+}
+
+var (
+	glider = []offset{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}
+	lwss   = []offset{
+		{1, 0}, {4, 0}, {0, 1}, {0, 2}, {4, 2}, {0, 3}, {1, 3}, {2, 3}, {3, 3},
+	}
+)
+
+// library holds the built-in patterns dropped by the library seeder. Envs
+// add those of the pattern directory.
+var library = append([][]offset{glider, lwss}, disruptions...)
+
+// transform applies one of the eight symmetries of the square to a pattern.
+func transform(pattern []offset, t int) []offset {
+	p := make([]offset, len(pattern))
+
+	for i, o := range pattern {
+		if t&4 != 0 {
+			o.x, o.y = o.y, o.x
+		}
+		if t&1 != 0 {
+			o.x = -o.x
+		}
+		if t&2 != 0 {
+			o.y = -o.y
+		}
+		p[i] = o
+	}
+
+	return p
+}
+
+// place sets the cells of a pattern at x, y in the next generation to
+// state c, wrapping them around the edges of the world.
+func (e *Env) place(pattern []offset, x, y, c int) {
+	for _, o := range pattern {
+		px, py := x+o.x, y+o.y
+		if px < 0 || px >= e.width || py < 0 || py >= e.height {
+			var ok bool
+			if px, py, ok = e.wrap(px, py, e.width, e.height); !ok {
+				continue
+			}
+		}
+		e.SetCell(getIdx(px, py, e.width), c)
+	}
+}
+
+func (e *Env) randomLiveCell() int {
+	return e.rand.Intn(e.inheritance.colors) + 1
+}
+
+func (e *Env) randomDeadZone() (int, int) {
+	return getCoords(e.deadZones[e.rand.Intn(len(e.deadZones))], e.width)
+}
+
+// seedDeadZone sets a random dead zone and its neighbors to random states.
+func seedDeadZone(e *Env) {
+	i := e.deadZones[e.rand.Intn(len(e.deadZones))]
+	e.seedCell(i)
+
+	for _, n := range e.getNeighbors(i) {
+		if n >= 0 {
+			e.seedCell(int(n))
+		}
+	}
+}
+
+// seedSpaceship sends a glider into the world from one of its edges.
+func seedSpaceship(e *Env) {
+	t := e.rand.Intn(4)
+	p := transform(glider, t)
+	x, y := e.rand.Intn(e.width), e.rand.Intn(e.height)
+
+	// Gliders of transform t head right unless t&1, and down unless t&2.
+	if e.rand.Intn(2) == 0 {
+		x = 0
+		if t&1 != 0 {
+			x = e.width - 1
+		}
+	} else {
+		y = 0
+		if t&2 != 0 {
+			y = e.height - 1
+		}
+	}
+
+	e.place(p, x, y, e.randomLiveCell())
+}
+
+const burstRadius = 2
+
+// seedSymmetric sets random cells around a dead zone, mirrored
+// horizontally and vertically.
+func seedSymmetric(e *Env) {
+	x, y := e.randomDeadZone()
+	c := e.randomLiveCell()
+
+	var p []offset
+	for dx := 0; dx <= burstRadius; dx++ {
+		for dy := 0; dy <= burstRadius; dy++ {
+			if e.rand.Intn(2) == 0 {
+				continue
+			}
+			for t := 0; t < 4; t++ {
+				p = append(p, transform([]offset{{dx, dy}}, t)...)
+			}
+		}
+	}
+
+	e.place(p, x, y, c)
+}
+
+const rainDrops = 4
+
+// seedRain sets single cells in random dead zones.
+func seedRain(e *Env) {
+	for i := 0; i < rainDrops; i++ {
+		e.SetCell(e.deadZones[e.rand.Intn(len(e.deadZones))],
+			e.randomLiveCell())
+	}
+}
+
+// seedLibrary drops a pattern of the library in a random orientation at a
+// random dead zone.
+func seedLibrary(e *Env) {
+	x, y := e.randomDeadZone()
+	p := e.library[e.rand.Intn(len(e.library))]
+
+	e.place(transform(p, e.rand.Intn(8)), x, y, e.randomLiveCell())
+}
@@ -0,0 +1,74 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseSeeders(t *testing.T) {
+	ws, err := parseSeeders([]string{"deadzone:3", " rain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [...]weightedSeeder{{name: "deadzone", weight: 3},
+		{name: "rain", weight: 1}}
+	for i, w := range ws {
+		if w.name != want[i].name || w.weight != want[i].weight {
+			t.Errorf("seeder[%d] = %s:%d; want %s:%d", i, w.name, w.weight,
+				want[i].name, want[i].weight)
+		}
+	}
+
+	for _, strs := range [...][]string{{}, {"drizzle"}, {"rain:0"},
+		{"rain:x"}} {
+		if _, err := parseSeeders(strs); err == nil {
+			t.Errorf("parseSeeders(%v) succeeded", strs)
+		}
+	}
+}
+
+func TestPickSeeder(t *testing.T) {
+	ws, _ := parseSeeders([]string{"deadzone:3", "rain"})
+	r := rand.New(rand.NewSource(1))
+	n := 0
+	for i := 0; i < 4000; i++ {
+		if pickSeeder(ws, r).name == "deadzone" {
+			n++
+		}
+	}
+	if n < 2800 || n > 3200 {
+		t.Errorf("deadzone picked %d times out of 4000; want ~3000", n)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	seen := make(map[[5]offset]bool)
+
+	for i := 0; i < 8; i++ {
+		var p [5]offset
+		copy(p[:], transform(glider, i))
+		seen[p] = true
+	}
+	if len(seen) != 8 {
+		t.Errorf("distinct orientations = %d; want 8", len(seen))
+	}
+}
+
+func TestSeeders(t *testing.T) {
+	for name, s := range seeders {
+		e := newTestEnv(t, 16, 16, withRule("B3/S23"))
+		copy(e.cells, make(Cells, e.size))
+		e.engine.step(e)
+		s.Seed(e)
+
+		n := 0
+		for _, c := range e.buffer {
+			if isLive(c) {
+				n++
+			}
+		}
+		if n == 0 {
+			t.Errorf("%s seeded no live cell", name)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package life
+
+// Methuselahs injected into stagnating worlds, as offsets from their top
+// left cell.
+var disruptions = [][]offset{
+	// R-pentomino
+	{{1, 0}, {2, 0}, {0, 1}, {1, 1}, {1, 2}},
+	// Acorn
+	{{1, 0}, {3, 1}, {0, 2}, {1, 2}, {4, 2}, {5, 2}, {6, 2}},
+	// B-heptomino
+	{{0, 0}, {2, 0}, {3, 0}, {0, 1}, {1, 1}, {2, 1}, {1, 2}},
+}
+
+// stagnation keeps the hashes of recent generations to detect cycles the
+// dead zones do not reveal, e.g. worlds of still lifes and oscillators.
+type stagnation struct {
+	hashes []uint64
+	n      int
+	ticks  int
+}
+
+func newStagnation(maxPeriod int) *stagnation {
+	return &stagnation{hashes: make([]uint64, maxPeriod)}
+}
+
+// hashCells hashes the states of cells, ignoring their colors, which may
+// change from one cycle to the next.
+func hashCells(cells Cells) uint64 {
+	h := uint64(14695981039346656037)
+
+	for _, c := range cells {
+		s := 0
+		if c != cellDead {
+			s = cellStage(c) + 1
+		}
+		h ^= uint64(s)
+		h *= 1099511628211
+	}
+
+	return h
+}
+
+// push records the hash of a generation, returning the period of the cycle
+// it closes, or 0.
+func (s *stagnation) push(h uint64) int {
+	max := len(s.hashes)
+	p := 0
+
+	for i := 1; i <= max && i <= s.n; i++ {
+		if s.hashes[(s.n-i)%max] == h {
+			p = i
+			break
+		}
+	}
+	s.hashes[s.n%max] = h
+	s.n++
+
+	return p
+}
+
+func (s *stagnation) reset() {
+	s.n = 0
+	s.ticks = 0
+}
+
+// checkStagnation looks for cycles in the next generation, responding once
+// one has lasted Stagnation.Ticks generations.
+func (e *Env) checkStagnation() {
+	if e.stagnation == nil {
+		return
+	}
+
+	s := e.stagnation
+	p := s.push(hashCells(e.buffer))
+	if p == 0 {
+		s.ticks = 0
+		return
+	}
+
+	s.ticks++
+	c := e.config.Stagnation
+	if s.ticks < c.Ticks {
+		return
+	}
+
+	logger.log("stagnation", "period = %d; %s...\n", p, c.Response)
+	e.event("stagnation %d; %s", p, c.Response)
+
+	switch c.Response {
+	case "reseed":
+		e.reseed(c.Density)
+	case "randomize":
+		e.reseed(1.0)
+	case "inject":
+		e.inject(disruptions[e.rand.Intn(len(disruptions))])
+	}
+	s.reset()
+}
+
+// reseed sets a fraction of the cells of the next generation to random
+// states.
+func (e *Env) reseed(density float64) {
+	for i := range e.buffer {
+		if density >= 1.0 || e.rand.Float64() < density {
+			e.seedCell(i)
+		}
+	}
+}
+
+// inject places a pattern of live cells at a random position of the next
+// generation.
+func (e *Env) inject(pattern []offset) {
+	e.place(pattern, e.rand.Intn(e.width), e.rand.Intn(e.height),
+		e.randomLiveCell())
+}
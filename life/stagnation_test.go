@@ -0,0 +1,61 @@
+package life
+
+import (
+	"testing"
+)
+
+func TestStagnationPush(t *testing.T) {
+	s := newStagnation(3)
+	hashes := [...]uint64{1, 2, 1, 1, 3, 4, 5, 3, 4, 5, 6}
+	want := [...]int{0, 0, 2, 1, 0, 0, 0, 3, 3, 3, 0}
+
+	for i, h := range hashes {
+		if p := s.push(h); p != want[i] {
+			t.Errorf("push %d (%d) = %d; want %d", i, h, p, want[i])
+		}
+	}
+}
+
+func TestHashCells(t *testing.T) {
+	a := Cells{0, 1, 2, 5, 0}
+	b := Cells{0, 3, 4, 8, 0}
+	c := Cells{0, 3, 4, 9, 0}
+
+	if hashCells(a) != hashCells(b) {
+		t.Errorf("hash of %v differs from %v", a, b)
+	}
+	if hashCells(a) == hashCells(c) {
+		t.Errorf("hash of %v equals %v", a, c)
+	}
+}
+
+func TestStagnationInject(t *testing.T) {
+	e := newTestEnv(t, 16, 16, withBlank, func(c *Config) {
+		c.Stagnation.MaxPeriod = 2
+		c.Stagnation.Ticks = 3
+		c.Stagnation.Response = "inject"
+	})
+	for _, i := range [...]int{17, 18, 33, 34} {
+		e.cells[i] = cellLive1
+	}
+
+	live := func() (n int) {
+		for _, c := range e.cells {
+			if isLive(c) {
+				n++
+			}
+		}
+		return n
+	}
+
+	for g := 0; g < 3; g++ {
+		e.tick()
+		if n := live(); n != 4 {
+			t.Fatalf("generation %d: live cells = %d; want 4", g, n)
+		}
+	}
+	e.tick()
+	if n := live(); n <= 4 {
+		t.Errorf("live cells = %d; want > 4", n)
+	}
+}
@@ -0,0 +1,197 @@
+package life
+
+import (
+	"encoding/gob"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// state holds what is needed to resume a world after a restart.
+type state struct {
+	Time                    time.Time
+	Rule                    string
+	Width                   int
+	Height                  int
+	Cells                   Cells
+	Colors                  []color.RGBA
+	Ages                    []uint16
+	SeedThreshold           float32
+	SeedThresholdDecayTicks int
+	SeedCooldownTicks       int
+	ColorScheme             [cellN]color.RGBA
+}
+
+// SaveState writes the world to path, replacing the previous state only
+// once the new one is completely written.
+func (e *Env) SaveState(path string) error {
+	s := state{
+		Time:                    time.Now(),
+		Rule:                    e.automaton.String(),
+		Width:                   e.width,
+		Height:                  e.height,
+		Cells:                   e.cells,
+		Colors:                  e.colors,
+		Ages:                    e.ages,
+		SeedThreshold:           e.seedThreshold,
+		SeedThresholdDecayTicks: e.seedThresholdDecayTicks,
+		SeedCooldownTicks:       e.seedCooldownTicks,
+	}
+	for i, c := range colorScheme {
+		s.ColorScheme[i] = toRGBA(c)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err = gob.NewEncoder(f).Encode(&s); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func readState(path string) (*state, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var s state
+	if err = gob.NewDecoder(f).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (e *Env) restore(s *state) error {
+	if s.Rule != e.automaton.String() {
+		return fmt.Errorf("rule = %s; does not match Rule = %s", s.Rule,
+			e.automaton)
+	}
+	if s.Width != e.width || s.Height != e.height ||
+		len(s.Cells) != e.size {
+		return fmt.Errorf("size = %dx%d; does not match %dx%d", s.Width,
+			s.Height, e.width, e.height)
+	}
+
+	copy(e.cells, s.Cells)
+	e.load()
+	if e.colors != nil && len(s.Colors) == e.size {
+		copy(e.colors, s.Colors)
+	}
+	if e.ages != nil && len(s.Ages) == e.size {
+		copy(e.ages, s.Ages)
+	}
+	e.seedThreshold = s.SeedThreshold
+	e.seedThresholdDecayTicks = s.SeedThresholdDecayTicks
+	e.seedCooldownTicks = s.SeedCooldownTicks
+
+	// Configured color schemes take precedence over generated ones.
+	if len(e.config.Color.Scheme) == 0 {
+		var cs ColorScheme
+		for i, c := range s.ColorScheme {
+			cs[i] = c
+		}
+		SetColorScheme(cs)
+	}
+
+	return nil
+}
+
+// LoadState restores the world saved at path, returning the time it was
+// saved.
+func (e *Env) LoadState(path string) (time.Time, error) {
+	s, err := readState(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Time, e.restore(s)
+}
+
+// resumes reports whether a world saved at saved is resumed at now.
+func (c *Config) resumes(saved, now time.Time) bool {
+	switch c.State.Resume {
+	case "always":
+		return true
+	case "sameday":
+		y, m, d := saved.Date()
+		ny, nm, nd := now.Date()
+		return y == ny && m == nm && d == nd
+	}
+	return false
+}
+
+// Resume restores the world from State.File, if the Resume policy allows
+// it. It returns false if the world has not been restored.
+func (e *Env) Resume() bool {
+	c := e.config
+	if c.State.File == "" || c.State.Resume == "never" {
+		return false
+	}
+
+	s, err := readState(c.State.File)
+	if err == nil && !c.resumes(s.Time, time.Now()) {
+		logger.log("state", "Not resuming state saved at %s\n", s.Time)
+		return false
+	}
+	if err == nil {
+		err = e.restore(s)
+	}
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("state: Failed to load '%s': %v\n", c.State.File, err)
+		}
+		return false
+	}
+
+	logger.log("state", "Resumed state saved at %s\n", s.Time)
+	e.event("resume %s", s.Time.Format(time.RFC3339))
+	return true
+}
+
+// Save writes the world to State.File, if set.
+func (e *Env) Save() {
+	c := e.config
+	if c.State.File == "" {
+		return
+	}
+
+	if err := e.SaveState(c.State.File); err != nil {
+		log.Printf("state: Failed to save '%s': %v\n", c.State.File, err)
+	}
+}
+
+// Suspend saves the world when the display turns off.
+func (e *Env) Suspend() {
+	e.event("suspend")
+	e.suspended = time.Now()
+	e.Save()
+}
+
+// Wake starts a new world when the display turns back on, unless the
+// Resume policy allows the suspended one to carry on.
+func (e *Env) Wake() {
+	e.event("wake")
+	if !e.config.resumes(e.suspended, time.Now()) {
+		e.Reset()
+	}
+}
@@ -0,0 +1,134 @@
+package life
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	defer SetColorScheme(colorScheme)
+
+	dir, err := ioutil.TempDir("", "lifelight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state")
+
+	e := newTestEnv(t, 16, 8, withRule("B3/S23"))
+	for g := 0; g < 5; g++ {
+		e.tick()
+	}
+	e.seedThreshold = 0.25
+	e.seedCooldownTicks = 3
+	if err := e.SaveState(path); err != nil {
+		t.Fatal(err)
+	}
+	scheme := colorScheme
+	SetColorScheme(ColorScheme{})
+
+	l := newTestEnv(t, 16, 8, withRule("B3/S23"))
+	if _, err := l.LoadState(path); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range l.cells {
+		if c != e.cells[i] {
+			t.Errorf("cell[%d] = %d; want %d", i, c, e.cells[i])
+		}
+	}
+	if l.seedThreshold != 0.25 || l.seedCooldownTicks != 3 {
+		t.Errorf("threshold = %f, cooldown = %d; want 0.25, 3",
+			l.seedThreshold, l.seedCooldownTicks)
+	}
+	for i, c := range colorScheme {
+		if toRGBA(c) != toRGBA(scheme[i]) {
+			t.Errorf("color[%d] = %v; want %v", i, c, scheme[i])
+		}
+	}
+
+	for _, m := range [...]*Env{
+		newTestEnv(t, 16, 8, withRule("B36/S23")),
+		newTestEnv(t, 8, 16, withRule("B3/S23")),
+	} {
+		if _, err := m.LoadState(path); err == nil {
+			t.Errorf("LoadState succeeded with %s on %dx%d", m.rule,
+				m.width, m.height)
+		}
+	}
+}
+
+func TestResumeGenerations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lifelight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Dying cells of Generations rules have states beyond the color scheme.
+	configure := func(c *Config) {
+		c.Rule = "B2/S/C3"
+		c.Color.Mode = "truecolor"
+		c.State.File = filepath.Join(dir, "state")
+	}
+	e := newTestEnv(t, 16, 8, configure)
+	for g := 0; g < 5; g++ {
+		e.tick()
+	}
+	e.Save()
+
+	r := newTestEnv(t, 16, 8, configure)
+	if !r.Resume() {
+		t.Fatal("world not resumed")
+	}
+	if !equalCells(r.cells, e.cells) {
+		t.Error("resumed world differs")
+	}
+
+	// Dying cells keep the color of their live state, dimmed as the
+	// original world draws them.
+	dying := 0
+	for i, c := range r.cells {
+		if c < cellN {
+			continue
+		}
+		dying++
+		if r.colors[i] != e.colors[i] {
+			t.Errorf("color[%d] = %v; want %v", i, r.colors[i], e.colors[i])
+		}
+		got := toRGBA(r.automaton.Color(r, i, c))
+		want := toRGBA(e.automaton.Color(e, i, c))
+		if got != want || got == toRGBA(r.colors[i]) {
+			t.Errorf("cell[%d] = %d drawn %v; want %v dimmed from %v", i, c,
+				got, want, r.colors[i])
+		}
+	}
+	if dying == 0 {
+		t.Fatal("no dying cells")
+	}
+	r.tick()
+}
+
+func TestResumes(t *testing.T) {
+	c := NewConfig()
+	now := time.Date(2021, 3, 5, 8, 0, 0, 0, time.Local)
+	yesterday := now.Add(-9 * time.Hour)
+	today := now.Add(-time.Hour)
+
+	want := map[string][2]bool{
+		"always":  {true, true},
+		"sameday": {false, true},
+		"never":   {false, false},
+	}
+	for mode, w := range want {
+		c.State.Resume = mode
+		for i, saved := range [...]time.Time{yesterday, today} {
+			if r := c.resumes(saved, now); r != w[i] {
+				t.Errorf("%s: resumes(%s) = %v; want %v", mode, saved, r,
+					w[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package life
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+func toRGBA(c color.Color) color.RGBA {
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
+
+func toColorful(c color.RGBA) colorful.Color {
+	return colorful.Color{
+		R: float64(c.R) / 255.0,
+		G: float64(c.G) / 255.0,
+		B: float64(c.B) / 255.0,
+	}
+}
+
+// schemeColor returns the color given by the color scheme to cells in state
+// c, dying cells keeping the color they had when alive.
+func schemeColor(c int) color.RGBA {
+	if c == cellDead {
+		return toRGBA(colorScheme[cellDead])
+	}
+	return toRGBA(colorScheme[cellColor(c)])
+}
+
+// blend mixes the colors of the parents of a newborn cell in HCL space,
+// then shifts the hue by up to mutation of a full turn.
+func blend(parents []color.RGBA, mutation float64, r *rand.Rand) color.RGBA {
+	var c colorful.Color
+
+	for i, p := range parents {
+		if i == 0 {
+			c = toColorful(p)
+			continue
+		}
+		c = c.BlendHcl(toColorful(p), 1.0/float64(i+1))
+	}
+
+	h, ch, l := c.Hcl()
+	h += (r.Float64()*2.0 - 1.0) * mutation * 360.0
+	c = colorful.Hcl(math.Mod(h+360.0, 360.0), ch, l).Clamped()
+	red, g, b := c.RGB255()
+
+	return color.RGBA{red, g, b, 255}
+}
+
+// trueColor returns the color of cell idx in the next generation.
+func (e *Env) trueColor(idx, c int, ns Neighbors, b *band) color.RGBA {
+	if c == cellDead {
+		return color.RGBA{}
+	}
+	if e.cells[idx] != cellDead {
+		return e.colors[idx]
+	}
+
+	b.parents = b.parents[:0]
+	for _, n := range ns {
+		if n >= 0 && isLive(e.cells[n]) {
+			b.parents = append(b.parents, e.colors[n])
+		}
+	}
+	if len(b.parents) == 0 {
+		return toRGBA(colorScheme[cellColor(c)])
+	}
+
+	return blend(b.parents, e.config.Color.Mutation, b.rand)
+}
@@ -0,0 +1,72 @@
+package life
+
+import (
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestBlend(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	r := rand.New(rand.NewSource(1))
+
+	if c := blend([]color.RGBA{red, red, red}, 0.0, r); c != red {
+		t.Errorf("blend = %v; want %v", c, red)
+	}
+
+	c := blend([]color.RGBA{red, {0, 0, 255, 255}}, 0.0, r)
+	if c.R == 0 || c.B == 0 {
+		t.Errorf("blend = %v; want mix of red and blue", c)
+	}
+}
+
+func TestEnvTrueColor(t *testing.T) {
+	e := newTestEnv(t, gliderWidth, gliderHeight, withBlank,
+		func(c *Config) {
+			c.Color.Mode = "truecolor"
+			c.Color.Mutation = 0.0
+		})
+	copy(e.cells, glider0)
+	for i, v := range e.cells {
+		e.colors[i] = toRGBA(colorScheme[v])
+	}
+
+	e.tick()
+
+	for i, v := range e.cells {
+		if v != glider1[i] {
+			t.Fatalf("cell = %d; want %d", v, glider1[i])
+		}
+		if v == cellDead && e.colors[i] != (color.RGBA{}) {
+			t.Errorf("color[%d] = %v; want black", i, e.colors[i])
+		}
+	}
+
+	// Surviving cells keep their colors.
+	i := getIdx(3, 3, gliderWidth)
+	if want := toRGBA(colorScheme[cellLive3]); e.colors[i] != want {
+		t.Errorf("color[%d] = %v; want %v", i, e.colors[i], want)
+	}
+}
+
+func benchmarkNewEnv(b *testing.B, mode string) {
+	c := NewConfig()
+	c.Color.Mode = mode
+	c.Hardware.MatrixWidth = 128
+	c.Hardware.MatrixHeight = 64
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewEnv(c)
+	}
+}
+
+// The difference in B/op between these benchmarks is the memory cost of the
+// true color buffers.
+func BenchmarkNewEnvPalette(b *testing.B) {
+	benchmarkNewEnv(b, "palette")
+}
+
+func BenchmarkNewEnvTrueColor(b *testing.B) {
+	benchmarkNewEnv(b, "truecolor")
+}
@@ -0,0 +1,255 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Directions of ants, clockwise from up, and the turns they make, in
+// quarters of a turn clockwise.
+var directions = [...]offset{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+var turns = map[byte]int{
+	'N': 0,
+	'R': 1,
+	'U': 2,
+	'L': 3,
+}
+
+// Turns of Golly's turmite tables.
+var tableTurns = map[int]int{
+	1: 0,
+	2: 1,
+	4: 2,
+	8: 3,
+}
+
+// A turmiteMove is what an ant in a given state does on a cell of a given
+// color: paint the cell, turn, switch to another state and move forward.
+type turmiteMove struct {
+	color int
+	turn  int
+	state int
+}
+
+type turmiteRule struct {
+	name string
+	// The moves of ants in every state, on every color.
+	table [][]turmiteMove
+}
+
+func (r turmiteRule) colors() int {
+	return len(r.table[0])
+}
+
+// parseTurnString parses the turns of Langton's ant generalizations, e.g.
+// RL: ants turn as given by the letter of the color of their cell, L, R, N
+// (no turn) or U (u-turn), which they paint with the next color.
+func parseTurnString(str string) ([]turmiteMove, error) {
+	if len(str) < 2 {
+		return nil, fmt.Errorf("must have at least two turns")
+	}
+
+	ms := make([]turmiteMove, len(str))
+	for i := range str {
+		t, ok := turns[str[i]]
+		if !ok {
+			return nil, fmt.Errorf("invalid turn '%c'", str[i])
+		}
+		ms[i] = turmiteMove{(i + 1) % len(str), t, 0}
+	}
+
+	return ms, nil
+}
+
+// parseTurmiteTable parses transition tables as written by Golly, e.g.
+// {{{1,2,0},{0,8,0}}} for Langton's ant: for every state, for every color,
+// the color to paint, the turn to make (1: none, 2: right, 4: u-turn,
+// 8: left) and the next state.
+func parseTurmiteTable(str string) ([][]turmiteMove, error) {
+	str = strings.Join(strings.Fields(str), "")
+	if !strings.HasPrefix(str, "{{{") || !strings.HasSuffix(str, "}}}") {
+		return nil, fmt.Errorf("must be enclosed in {{{ }}}")
+	}
+
+	var table [][]turmiteMove
+	for _, s := range strings.Split(str[3:len(str)-3], "}},{{") {
+		var ms []turmiteMove
+		for _, m := range strings.Split(s, "},{") {
+			fs := strings.Split(m, ",")
+			if len(fs) != 3 {
+				return nil, fmt.Errorf("invalid move '{%s}'", m)
+			}
+			var vs [3]int
+			for i, f := range fs {
+				v, err := strconv.Atoi(f)
+				if err != nil {
+					return nil, fmt.Errorf("invalid move '{%s}'", m)
+				}
+				vs[i] = v
+			}
+			t, ok := tableTurns[vs[1]]
+			if !ok {
+				return nil, fmt.Errorf("invalid turn %d", vs[1])
+			}
+			ms = append(ms, turmiteMove{vs[0], t, vs[2]})
+		}
+		table = append(table, ms)
+	}
+
+	for _, ms := range table {
+		if len(ms) != len(table[0]) || len(ms) < 2 {
+			return nil, fmt.Errorf("every state must have moves for the " +
+				"same number of colors, at least two")
+		}
+		for _, m := range ms {
+			if m.color >= len(ms) || m.color < 0 {
+				return nil, fmt.Errorf("invalid color %d", m.color)
+			}
+			if m.state >= len(table) || m.state < 0 {
+				return nil, fmt.Errorf("invalid state %d", m.state)
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// parseTurmite parses either a turn string or a transition table.
+func parseTurmite(str string) (turmiteRule, error) {
+	str = strings.TrimSpace(str)
+	r := turmiteRule{name: str}
+
+	if strings.HasPrefix(str, "{") {
+		table, err := parseTurmiteTable(str)
+		r.table = table
+		return r, err
+	}
+
+	ms, err := parseTurnString(strings.ToUpper(str))
+	r.table = [][]turmiteMove{ms}
+	return r, err
+}
+
+type ant struct {
+	x     int
+	y     int
+	dir   int
+	state int
+}
+
+// turmite runs ants painting the cells they walk on, cells holding colors.
+type turmite struct {
+	rule      turmiteRule
+	ants      []ant
+	at        []int
+	highlight color.Color
+	palette   []color.Color
+	scheme    ColorScheme
+}
+
+func newTurmite(e *Env) Automaton {
+	c := e.config
+	a := &turmite{
+		rule:      c.turmite,
+		ants:      make([]ant, c.Turmite.Ants),
+		at:        make([]int, e.size),
+		highlight: c.turmiteHighlight,
+	}
+	a.place(e)
+
+	return a
+}
+
+// place puts the first ant in the middle, facing up, and the others
+// anywhere.
+func (a *turmite) place(e *Env) {
+	for i := range a.at {
+		a.at[i] = 0
+	}
+	for i := range a.ants {
+		t := ant{x: e.width / 2, y: e.height / 2}
+		if i > 0 {
+			t = ant{x: e.rand.Intn(e.width), y: e.rand.Intn(e.height),
+				dir: e.rand.Intn(len(directions))}
+		}
+		a.ants[i] = t
+		a.at[getIdx(t.x, t.y, e.width)]++
+	}
+}
+
+func (a *turmite) String() string {
+	return a.rule.name
+}
+
+func (a *turmite) States() int {
+	return a.rule.colors()
+}
+
+// Step moves the ants one after the other, so that ants on the same cell
+// both paint it.
+func (a *turmite) Step(e *Env) {
+	copy(e.buffer, e.cells)
+
+	for i := range a.ants {
+		t := &a.ants[i]
+		idx := getIdx(t.x, t.y, e.width)
+		m := a.rule.table[t.state][e.buffer[idx]]
+
+		e.buffer[idx] = m.color
+		t.dir = (t.dir + m.turn) % len(directions)
+		t.state = m.state
+
+		// Ants turn around at dead borders.
+		d := directions[t.dir]
+		x, y, ok := e.wrap(t.x+d.x, t.y+d.y, e.width, e.height)
+		if !ok {
+			t.dir = (t.dir + 2) % len(directions)
+			continue
+		}
+		a.at[idx]--
+		t.x, t.y = x, y
+		a.at[getIdx(x, y, e.width)]++
+	}
+}
+
+// Seed does nothing, ants never running out of cells to paint.
+func (a *turmite) Seed(e *Env) {
+}
+
+// Reseed paints a fraction density of the cells with random colors.
+func (a *turmite) Reseed(e *Env, density float64) {
+	for i := range e.buffer {
+		if e.rand.Float64() < density {
+			e.buffer[i] = e.rand.Intn(a.States())
+		}
+	}
+}
+
+// Randomize clears the cells and puts the ants back in place, ants making
+// their patterns on blank cells.
+func (a *turmite) Randomize(e *Env) {
+	for i := range e.cells {
+		e.cells[i] = 0
+	}
+	a.place(e)
+}
+
+func (a *turmite) Load(e *Env) {
+}
+
+// Color highlights ants, unless the highlight is nil, cells being colored
+// along a gradient through the live colors of the color scheme.
+func (a *turmite) Color(e *Env, idx, c int) color.Color {
+	if a.highlight != nil && a.at[idx] > 0 {
+		return a.highlight
+	}
+	if a.palette == nil || a.scheme != colorScheme {
+		a.palette = append([]color.Color{colorScheme[cellDead]},
+			gradient(colorScheme[cellLive1:], a.States()-1, false)...)
+		a.scheme = colorScheme
+	}
+	return a.palette[c]
+}
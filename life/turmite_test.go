@@ -0,0 +1,145 @@
+package life
+
+import (
+	"bytes"
+	"testing"
+)
+
+func withTurmite(rule string, ants int) func(c *Config) {
+	return func(c *Config) {
+		c.Automaton = "turmite"
+		c.Turmite.Rule = rule
+		c.Turmite.Ants = ants
+	}
+}
+
+func TestParseTurmite(t *testing.T) {
+	for str, colors := range map[string]int{
+		"RL":                  2,
+		"llrr":                4,
+		"RLNU":                4,
+		"{{{1,2,0},{0,8,0}}}": 2,
+		"{{{1, 2, 1}, {1, 8, 1}}, {{1, 2, 1}, {0, 1, 0}}}": 2,
+	} {
+		r, err := parseTurmite(str)
+		if err != nil {
+			t.Errorf("parseTurmite(%q): %v", str, err)
+		} else if r.colors() != colors {
+			t.Errorf("parseTurmite(%q) colors = %d; want %d", str,
+				r.colors(), colors)
+		}
+	}
+
+	for _, str := range [...]string{"", "R", "RX", "{{1,2,0},{0,8,0}}",
+		"{{{1,2,0},{0,3,0}}}", "{{{2,2,0},{0,8,0}}}", "{{{1,2,1},{0,8,0}}}",
+		"{{{1,2,0}}}", "{{{1,2,0},{0,8,0}},{{1,2,0}}}"} {
+		if _, err := parseTurmite(str); err == nil {
+			t.Errorf("parseTurmite(%q) succeeded", str)
+		}
+	}
+}
+
+func TestCheckTurmite(t *testing.T) {
+	for i, f := range [...]func(c *Config){
+		func(c *Config) { c.Turmite.Rule = "RX" },
+		func(c *Config) { c.Turmite.Ants = 0 },
+		func(c *Config) { c.History.Generations = 10 },
+		func(c *Config) { c.State.File = "state" },
+	} {
+		c := NewConfig()
+		withTurmite("RL", 1)(c)
+		f(c)
+		if err := c.checkTurmite(); err == nil {
+			t.Errorf("checkTurmite of config %d succeeded", i)
+		}
+	}
+
+	// Other automata keep their history and state.
+	c := NewConfig()
+	c.History.Generations = 10
+	c.State.File = "state"
+	if err := c.checkTurmite(); err != nil {
+		t.Errorf("checkTurmite of life: %v", err)
+	}
+}
+
+func TestTurmiteStep(t *testing.T) {
+	e := newTestEnv(t, 32, 32, withTurmite("RL", 1))
+	a := e.automaton.(*turmite)
+	x, y := e.width/2, e.height/2
+
+	e.tick()
+	if c := e.cells[getIdx(x, y, e.width)]; c != 1 {
+		t.Errorf("painted cell = %d; want 1", c)
+	}
+	if ant := a.ants[0]; ant.x != x+1 || ant.y != y || ant.dir != 1 {
+		t.Errorf("ant = %+v; want at %d, %d facing right", ant, x+1, y)
+	}
+	if e.automaton.Color(e, getIdx(x+1, y, e.width), 0) != a.highlight {
+		t.Error("ant is not highlighted")
+	}
+}
+
+func TestTurmiteTable(t *testing.T) {
+	e := newTestEnv(t, 32, 32, withTurmite("RL", 1))
+	te := newTestEnv(t, 32, 32, withTurmite("{{{1,2,0},{0,8,0}}}", 1))
+
+	for g := 0; g < 1000; g++ {
+		e.tick()
+		te.tick()
+	}
+	if !equalCells(e.cells, te.cells) {
+		t.Error("transition table of Langton's ant differs from RL")
+	}
+}
+
+func TestTurmiteAnts(t *testing.T) {
+	e := newTestEnv(t, 32, 32, withTurmite("LLRR", 3),
+		withTopology("bounded"))
+
+	for g := 0; g < 2000; g++ {
+		e.tick()
+	}
+
+	a := e.automaton.(*turmite)
+	n := 0
+	for _, c := range a.at {
+		n += c
+	}
+	if n != 3 {
+		t.Errorf("ants = %d; want 3", n)
+	}
+	for _, ant := range a.ants {
+		if ant.x < 0 || ant.x >= e.width || ant.y < 0 || ant.y >= e.height {
+			t.Errorf("ant = %+v; out of bounds", ant)
+		}
+	}
+}
+
+func TestTurmiteReplay(t *testing.T) {
+	e := newTestEnv(t, 32, 32, withTurmite("RL", 1))
+	var b bytes.Buffer
+	rc, err := NewRecorder(e, &b, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for g := 0; g < 20; g++ {
+		e.tick()
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPlayer(e.config, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hl := e.config.turmiteHighlight
+	for p.Next() == nil {
+		for i, c := range p.env.cells {
+			if p.env.automaton.Color(p.env, i, c) == hl {
+				t.Fatalf("frame %d: cell[%d] highlighted", p.Frames(), i)
+			}
+		}
+	}
+}
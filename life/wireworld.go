@@ -0,0 +1,179 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"lifelight/pattern"
+)
+
+const wireworldStates = 4
+
+const (
+	wireEmpty     = pattern.WireworldEmpty
+	wireHead      = pattern.WireworldHead
+	wireTail      = pattern.WireworldTail
+	wireConductor = pattern.WireworldConductor
+)
+
+func checkWireworldPattern(p *pattern.Pattern) error {
+	if p.Rule != "" && !strings.EqualFold(p.Rule, "WireWorld") {
+		return fmt.Errorf("rule = %s; does not match Automaton = wireworld",
+			p.Rule)
+	}
+	if p.States() > wireworldStates {
+		return fmt.Errorf("states = %d; must be <= %d", p.States(),
+			wireworldStates)
+	}
+	return nil
+}
+
+// wireworld runs electrons along conductors: heads become tails, tails
+// become conductors again, and conductors become heads next to one or two
+// heads.
+type wireworld struct {
+	colors []color.Color
+	moore  []offset
+}
+
+func newWireworld(e *Env) Automaton {
+	return wireworld{
+		colors: e.config.wireworldColors,
+		moore:  moore.offsets(1)[0],
+	}
+}
+
+func (wireworld) String() string {
+	return "WireWorld"
+}
+
+func (wireworld) States() int {
+	return wireworldStates
+}
+
+func (a wireworld) heads(e *Env, idx int) int {
+	x, y := getCoords(idx, e.width)
+	n := 0
+
+	for _, o := range a.moore {
+		nx, ny, ok := e.wrap(x+o.x, y+o.y, e.width, e.height)
+		if ok && e.cells[getIdx(nx, ny, e.width)] == wireHead {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (a wireworld) Step(e *Env) {
+	e.parallel(func(b *band) {
+		first, last := b.cells(e.width)
+
+		for i := first; i < last; i++ {
+			switch c := e.cells[i]; c {
+			case wireHead:
+				e.buffer[i] = wireTail
+			case wireTail:
+				e.buffer[i] = wireConductor
+			case wireConductor:
+				if n := a.heads(e, i); n == 1 || n == 2 {
+					e.buffer[i] = wireHead
+				} else {
+					e.buffer[i] = wireConductor
+				}
+			default:
+				e.buffer[i] = c
+			}
+		}
+	})
+}
+
+// conductors returns the conductors of the next generation.
+func conductors(e *Env) []int {
+	var cs []int
+	for i, c := range e.buffer {
+		if c == wireConductor {
+			cs = append(cs, i)
+		}
+	}
+	return cs
+}
+
+// Seed sends an electron down a random conductor once none are left, so
+// that circuits do not go dark.
+func (wireworld) Seed(e *Env) {
+	for _, c := range e.buffer {
+		if c == wireHead || c == wireTail {
+			return
+		}
+	}
+	if cs := conductors(e); len(cs) > 0 {
+		e.SetCell(cs[e.rand.Intn(len(cs))], wireHead)
+	}
+}
+
+// Reseed sends electrons down a fraction density of the conductors.
+func (wireworld) Reseed(e *Env, density float64) {
+	for _, i := range conductors(e) {
+		if e.rand.Float64() < density {
+			e.SetCell(i, wireHead)
+		}
+	}
+}
+
+// Randomize lays out clocks, loops of conductor around which an electron
+// runs, sending electrons down wires leading off them.
+func (wireworld) Randomize(e *Env) {
+	for i := range e.cells {
+		e.cells[i] = wireEmpty
+	}
+
+	set := func(x, y, c int) {
+		if x, y, ok := e.wrap(x, y, e.width, e.height); ok {
+			e.cells[getIdx(x, y, e.width)] = c
+		}
+	}
+
+	for n := e.size/256 + 1; n > 0; n-- {
+		w, h := e.rand.Intn(5)+3, e.rand.Intn(5)+3
+		x0, y0 := e.rand.Intn(e.width), e.rand.Intn(e.height)
+
+		// The loop runs clockwise from the top left corner, which is cut
+		// like every other so that electrons only run one way.
+		var loop []offset
+		for x := 1; x < w-1; x++ {
+			loop = append(loop, offset{x, 0})
+		}
+		for y := 1; y < h-1; y++ {
+			loop = append(loop, offset{w - 1, y})
+		}
+		for x := w - 2; x > 0; x-- {
+			loop = append(loop, offset{x, h - 1})
+		}
+		for y := h - 2; y > 0; y-- {
+			loop = append(loop, offset{0, y})
+		}
+		for _, o := range loop {
+			set(x0+o.x, y0+o.y, wireConductor)
+		}
+
+		// The wire leads off the right side of the loop.
+		y, l := y0+e.rand.Intn(h-2)+1, e.rand.Intn(e.width/2+1)+2
+		for x := 0; x < l; x++ {
+			set(x0+w+x, y, wireConductor)
+		}
+
+		i := e.rand.Intn(len(loop))
+		head, tail := loop[i], loop[(i+len(loop)-1)%len(loop)]
+		set(x0+head.x, y0+head.y, wireHead)
+		set(x0+tail.x, y0+tail.y, wireTail)
+	}
+}
+
+func (wireworld) Load(e *Env) {
+}
+
+func (a wireworld) Color(e *Env, idx, c int) color.Color {
+	return a.colors[c]
+}
@@ -0,0 +1,100 @@
+package life
+
+import (
+	"strings"
+	"testing"
+
+	"lifelight/pattern"
+)
+
+func withWireworld(c *Config) {
+	c.Automaton = "wireworld"
+	c.Topology = "bounded"
+}
+
+func loadWire(t *testing.T, e *Env, str string) {
+	p, err := pattern.ReadWireworld(strings.NewReader(str))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.LoadPattern(p)
+}
+
+func TestWireworldStep(t *testing.T) {
+	e := newTestEnv(t, 5, 1, withWireworld)
+	loadWire(t, e, "tH###")
+
+	want := [...]Cells{
+		{wireConductor, wireTail, wireHead, wireConductor, wireConductor},
+		{wireConductor, wireConductor, wireTail, wireHead, wireConductor},
+	}
+	for g, w := range want {
+		e.tick()
+		for i, c := range e.cells {
+			if c != w[i] {
+				t.Fatalf("generation %d: cell[%d] = %d; want %d", g+1, i, c,
+					w[i])
+			}
+		}
+	}
+}
+
+func TestWireworldClock(t *testing.T) {
+	e := newTestEnv(t, 4, 3, withWireworld)
+	loadWire(t, e, " tH \n#  #\n ## ")
+	first := append(Cells{}, e.cells...)
+
+	for g := 1; g <= 6; g++ {
+		e.tick()
+		if equalCells(e.cells, first) != (g == 6) {
+			t.Errorf("generation %d: clock period is not 6", g)
+		}
+	}
+}
+
+func TestWireworldSeed(t *testing.T) {
+	e := newTestEnv(t, 4, 1, withWireworld)
+	loadWire(t, e, "####")
+
+	e.tick()
+	n := 0
+	for _, c := range e.cells {
+		if c == wireHead {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("heads = %d; want 1", n)
+	}
+}
+
+func TestWireworldRandomize(t *testing.T) {
+	e := newTestEnv(t, 32, 32, withWireworld)
+
+	var n [wireworldStates]int
+	for _, c := range e.cells {
+		n[c]++
+	}
+	if n[wireHead] == 0 || n[wireTail] == 0 || n[wireConductor] == 0 {
+		t.Errorf("states = %v; want heads, tails and conductors", n)
+	}
+}
+
+func TestCheckWireworldPattern(t *testing.T) {
+	c := NewConfig()
+	c.Automaton = "wireworld"
+
+	for _, p := range [...]*pattern.Pattern{
+		{Rule: "B3/S23", Width: 1, Height: 1, Cells: []int{1}},
+		{Width: 1, Height: 1, Cells: []int{4}},
+	} {
+		if err := c.checkPattern(p); err == nil {
+			t.Errorf("checkPattern(%v) succeeded", p)
+		}
+	}
+	p := &pattern.Pattern{Rule: "WireWorld", Width: 1, Height: 1,
+		Cells: []int{3}}
+	if err := c.checkPattern(p); err != nil {
+		t.Errorf("checkPattern(%v): %v", p, err)
+	}
+}
@@ -1,30 +1,27 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
-	"image/color"
+	"io"
 	"log"
-	"math/rand"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"lifelight/life"
 
 	"github.com/jcrd/go-rpi-rgb-led-matrix"
-	"github.com/lucasb-eyer/go-colorful"
 )
 
 var version = ""
 
 var configPath = "/etc/lifelight.ini"
 
-var colorPalettes = map[string]func(int) ([]colorful.Color, error){
-	"happy": colorful.HappyPalette,
-	"soft":  colorful.SoftPalette,
-	"warm":  colorful.WarmPalette,
-}
-
 func newCanvas(c *life.Config) *rgbmatrix.Canvas {
 	config := rgbmatrix.DefaultConfig
 	config.Cols = c.Hardware.MatrixWidth
@@ -39,42 +36,6 @@ func newCanvas(c *life.Config) *rgbmatrix.Canvas {
 	return rgbmatrix.NewCanvas(matrix)
 }
 
-func makeColorScheme(hs []string) (life.ColorScheme, error) {
-	cs := life.ColorScheme{
-		color.Black,
-	}
-
-	for i, h := range hs {
-		c, err := colorful.Hex(h)
-		if err != nil {
-			return cs, fmt.Errorf("%s (%v)", h, err)
-		}
-		cs[i+1] = c
-	}
-
-	return cs, nil
-}
-
-func genColors(ps []string) {
-	p := ps[rand.Intn(len(ps))]
-	colors, err := colorPalettes[p](life.LiveCellN)
-
-	if err != nil {
-		log.Printf("color: Failed to generate palette '%s': %v\n", p, err)
-		colors = colorful.FastHappyPalette(life.LiveCellN)
-	}
-
-	cs := life.ColorScheme{
-		color.Black,
-	}
-
-	for i, c := range colors {
-		cs[i+1] = c
-	}
-
-	life.SetColorScheme(cs)
-}
-
 func initialState(c *life.Config) bool {
 	t := time.Now()
 
@@ -108,7 +69,117 @@ func updateScheduleState(c *life.Config, toggle chan<- struct{}) {
 	}
 }
 
+// readCommands reads commands from the FIFO at path, creating it if
+// needed, and sends them to cmds.
+func readCommands(path string, cmds chan<- life.Command) {
+	if err := syscall.Mkfifo(path, 0600); err != nil && !os.IsExist(err) {
+		log.Printf("control: %v\n", err)
+		return
+	}
+
+	for {
+		// Opening the FIFO blocks until there is a writer, and reading it
+		// ends when the writer closes it.
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("control: %v\n", err)
+			return
+		}
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			if strings.TrimSpace(s.Text()) == "" {
+				continue
+			}
+			cmd, err := life.ParseCommand(s.Text())
+			if err != nil {
+				log.Printf("control: %v\n", err)
+				continue
+			}
+			cmds <- cmd
+		}
+		f.Close()
+	}
+}
+
+// runCommand carries out cmd, returning whether ticking is paused.
+func runCommand(c *life.Config, e *life.Env, canvas *rgbmatrix.Canvas,
+	cmd life.Command, paused bool) bool {
+	switch cmd.Name {
+	case "pause":
+		paused = true
+	case "play":
+		paused = false
+	case "back":
+		e.Rewind(cmd.N)
+		paused = true
+	case "forward":
+		e.Forward(cmd.N)
+		paused = true
+	case "rewind":
+		e.Rewind(cmd.N * c.TicksPerSecond)
+		paused = false
+	case "reseed":
+		e.Reseed()
+		paused = false
+	case "advance":
+		if n := e.Advance(cmd.N); n < cmd.N {
+			log.Printf("control: advance: %d generations at most with the "+
+				"%s engine\n", n, c.Engine)
+		}
+	}
+	e.Draw(canvas)
+
+	log.Printf("control: %s: %d generations back, %d more in history\n",
+		cmd.Name, e.Behind(), e.History())
+
+	return paused
+}
+
+// replay plays a recording back on the matrix, speed times faster than it
+// was recorded.
+func replay(c *life.Config, path string, speed float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p, err := life.NewPlayer(c, f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	c.Hardware.MatrixWidth, c.Hardware.MatrixHeight = p.Size()
+
+	canvas := newCanvas(c)
+	defer canvas.Close()
+
+	fmt.Println("replaying:", path)
+	fmt.Println("seed:", p.Seed())
+
+	tick := float64(time.Second) / (float64(p.TicksPerSecond()) * speed)
+	ticker := time.NewTicker(time.Duration(tick))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := p.Next(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		for _, e := range p.Events {
+			log.Printf("replay: Frame %d: %s\n", p.Frames(), e)
+		}
+		p.Draw(canvas)
+	}
+
+	return nil
+}
+
 func main() {
+	replayPath := flag.String("replay", "", "play a recording back")
+	speed := flag.Float64("speed", 1.0, "speed of the replay")
+	flag.Parse()
+
 	if v := os.Getenv("LIFELIGHT_DEBUG"); v != "" {
 		life.InitLogger(v)
 	}
@@ -125,10 +196,30 @@ func main() {
 		return
 	}
 
-	rand.Seed(time.Now().UnixNano())
+	if *replayPath != "" {
+		if *speed <= 0 {
+			log.Printf("replay: Speed = %f; must be > 0\n", *speed)
+			return
+		}
+		if err := replay(c, *replayPath, *speed); err != nil {
+			log.Printf("replay: %v\n", err)
+		}
+		return
+	}
+
+	if v, ok := os.LookupEnv("LIFELIGHT_SEED"); ok {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Printf("LIFELIGHT_SEED = %s; must be an integer\n", v)
+			return
+		}
+		c.Seed = seed
+	}
+
+	e := life.NewEnv(c)
 
 	if len(c.Color.Scheme) > 0 {
-		cs, err := makeColorScheme(c.Color.Scheme)
+		cs, err := life.ParseColorScheme(c.Color.Scheme)
 		if err != nil {
 			log.Printf("config: Failed to parse color: %v\n", err)
 			return
@@ -136,14 +227,24 @@ func main() {
 		life.SetColorScheme(cs)
 		c.Color.ScheduleRegen = false
 	} else {
-		genColors(c.Color.Palettes)
+		e.GenColors()
 	}
 
 	canvas := newCanvas(c)
 	defer canvas.Close()
 
-	e := life.NewEnv(c)
-	e.Randomize()
+	if !e.Resume() {
+		e.Reset()
+	}
+
+	if c.Record.Dir != "" {
+		if rc, path, err := e.Record(); err != nil {
+			log.Printf("record: %v\n", err)
+		} else {
+			defer rc.Close()
+			fmt.Println("recording:", path)
+		}
+	}
 
 	ticks := time.Second / time.Duration(c.TicksPerSecond)
 	ticker := time.NewTicker(ticks)
@@ -151,7 +252,28 @@ func main() {
 
 	toggle := make(chan struct{})
 
+	export := make(chan os.Signal, 1)
+	signal.Notify(export, syscall.SIGUSR1)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, os.Interrupt)
+
+	cmds := make(chan life.Command)
+	if c.Control.Fifo != "" {
+		go readCommands(c.Control.Fifo, cmds)
+	}
+	paused := false
+
+	var save <-chan time.Time
+	if c.State.File != "" && c.State.Interval > 0 {
+		t := time.NewTicker(c.State.Interval)
+		defer t.Stop()
+		save = t.C
+	}
+
 	fmt.Println("running:", version)
+	fmt.Println("automaton:", e.Automaton())
+	fmt.Println("seed:", e.Seed())
 
 	if c.Schedule && c.NumSchedules() > 0 {
 		go updateScheduleState(c, toggle)
@@ -161,12 +283,35 @@ func main() {
 		select {
 		case <-toggle:
 			e.Clear(canvas)
+			e.Suspend()
 			if c.Color.ScheduleRegen {
-				genColors(c.Color.Palettes)
+				e.GenColors()
 			}
-			<-toggle
+			select {
+			case <-toggle:
+				e.Wake()
+			case <-quit:
+				return
+			}
+		case <-save:
+			e.Save()
+		case <-quit:
+			e.Save()
+			return
+		case <-export:
+			paths, err := e.Export()
+			if err != nil {
+				log.Printf("export: %v\n", err)
+			}
+			for _, p := range paths {
+				log.Printf("export: Saved '%s'\n", p)
+			}
+		case cmd := <-cmds:
+			paused = runCommand(c, e, canvas, cmd, paused)
 		case <-ticker.C:
-			e.Update(canvas)
+			if !paused {
+				e.Update(canvas)
+			}
 		}
 	}
 }
@@ -0,0 +1,493 @@
+// Package pattern reads cellular automata patterns in the RLE, plaintext
+// (.cells), Life 1.06 and MCell formats, and Wireworld circuits drawn in
+// text.
+package pattern
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A Pattern is a rectangle of cell states, 0 being dead.
+type Pattern struct {
+	Name     string
+	Comments []string
+	Rule     string
+	Width    int
+	Height   int
+	Cells    []int
+}
+
+// Patterns wider or taller than this are rejected, so that a single
+// malformed file cannot exhaust memory.
+const maxSize = 1 << 10
+
+var errSize = fmt.Errorf("larger than %dx%d cells", maxSize, maxSize)
+
+type cell struct {
+	x     int
+	y     int
+	state int
+}
+
+// newPattern makes a pattern large enough to hold cells, shifted so that
+// the top left cell is at 0, 0 unless the size is given.
+func newPattern(cs []cell, width, height int, shift bool) (*Pattern, error) {
+	minX, minY := 0, 0
+	if shift && len(cs) > 0 {
+		minX, minY = cs[0].x, cs[0].y
+		for _, c := range cs {
+			if c.x < minX {
+				minX = c.x
+			}
+			if c.y < minY {
+				minY = c.y
+			}
+		}
+	}
+
+	for _, c := range cs {
+		// Differences of far apart coordinates may overflow.
+		x, y := c.x-minX, c.y-minY
+		if x < 0 || x >= maxSize || y < 0 || y >= maxSize {
+			return nil, errSize
+		}
+		if x >= width {
+			width = x + 1
+		}
+		if y >= height {
+			height = y + 1
+		}
+	}
+	if width > maxSize || height > maxSize {
+		return nil, errSize
+	}
+
+	p := &Pattern{
+		Width:  width,
+		Height: height,
+		Cells:  make([]int, width*height),
+	}
+	for _, c := range cs {
+		p.Cells[(c.y-minY)*width+c.x-minX] = c.state
+	}
+
+	return p, nil
+}
+
+// At returns the state of the cell at x, y.
+func (p *Pattern) At(x, y int) int {
+	return p.Cells[y*p.Width+x]
+}
+
+// States returns the number of states used by the pattern.
+func (p *Pattern) States() int {
+	n := 1
+	for _, s := range p.Cells {
+		if s >= n {
+			n = s + 1
+		}
+	}
+	return n
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		lines = append(lines, strings.TrimRight(s.Text(), " \t\r"))
+	}
+
+	return lines, s.Err()
+}
+
+// parseHeader parses the x = .., y = .., rule = .. line of RLE patterns.
+func parseHeader(line string) (width, height int, rule string, err error) {
+	for _, f := range strings.Split(line, ",") {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return 0, 0, "", fmt.Errorf("invalid header field '%s'", f)
+		}
+		k, v := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch k {
+		case "x":
+			width, err = strconv.Atoi(v)
+		case "y":
+			height, err = strconv.Atoi(v)
+		case "rule":
+			rule = v
+		}
+		if err != nil || width < 0 || height < 0 {
+			return 0, 0, "", fmt.Errorf("invalid size '%s'", f)
+		}
+	}
+
+	return width, height, rule, nil
+}
+
+// parseState parses the state of a run of cells, either b, o, ., A-X or a
+// prefix p-y followed by A-X, returning the length of the tag.
+func parseState(str string) (int, int, error) {
+	switch c := str[0]; {
+	case c == 'b' || c == '.':
+		return 0, 1, nil
+	case c == 'o':
+		return 1, 1, nil
+	case c >= 'A' && c <= 'X':
+		return int(c-'A') + 1, 1, nil
+	case c >= 'p' && c <= 'y' && len(str) > 1 &&
+		str[1] >= 'A' && str[1] <= 'X':
+		return int(c-'p'+1)*24 + int(str[1]-'A') + 1, 2, nil
+	}
+	return 0, 0, fmt.Errorf("invalid state '%c'", str[0])
+}
+
+func ReadRLE(r io.Reader) (*Pattern, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var name, rule string
+	var comments []string
+	var width, height int
+	var body strings.Builder
+	header := false
+
+	for _, line := range lines {
+		switch {
+		case header:
+			body.WriteString(strings.TrimSpace(line))
+		case strings.HasPrefix(line, "#N"):
+			name = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, "#C") || strings.HasPrefix(line, "#c") ||
+			strings.HasPrefix(line, "#O"):
+			comments = append(comments, strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, "#"), line == "":
+		default:
+			if width, height, rule, err = parseHeader(line); err != nil {
+				return nil, err
+			}
+			header = true
+		}
+	}
+	if !header {
+		return nil, fmt.Errorf("missing header")
+	}
+
+	cs, end, err := readRuns(body.String())
+	if err != nil {
+		return nil, err
+	}
+	if !end {
+		return nil, fmt.Errorf("missing '!'")
+	}
+
+	p, err := newPattern(cs, width, height, false)
+	if err != nil {
+		return nil, err
+	}
+	p.Name, p.Comments, p.Rule = name, comments, rule
+
+	return p, nil
+}
+
+// readRuns reads the runs of cells of RLE and MCell patterns, up to the
+// end of str or to '!', reporting whether it was reached.
+func readRuns(str string) ([]cell, bool, error) {
+	var cs []cell
+	x, y, n := 0, 0, 0
+
+	for i := 0; i < len(str); {
+		c := str[i]
+		switch {
+		case c >= '0' && c <= '9':
+			if n = n*10 + int(c-'0'); n > maxSize {
+				return nil, false, errSize
+			}
+			i++
+			continue
+		case c == '!':
+			return cs, true, nil
+		case c == ' ' || c == '\t':
+			i++
+			continue
+		}
+
+		if n == 0 {
+			n = 1
+		}
+		if c == '$' {
+			x, y = 0, y+n
+			i++
+		} else {
+			s, l, err := parseState(str[i:])
+			if err != nil {
+				return nil, false, err
+			}
+			if s > 0 && (x+n > maxSize || y >= maxSize) {
+				return nil, false, errSize
+			}
+			for j := 0; s > 0 && j < n; j++ {
+				cs = append(cs, cell{x + j, y, s})
+			}
+			x += n
+			i += l
+		}
+		n = 0
+	}
+
+	return cs, false, nil
+}
+
+func ReadPlaintext(r io.Reader) (*Pattern, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var name string
+	var comments []string
+	var cs []cell
+	y := 0
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "!Name:") {
+			name = strings.TrimSpace(line[6:])
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			comments = append(comments, strings.TrimSpace(line[1:]))
+			continue
+		}
+		for x, c := range line {
+			switch c {
+			case '.':
+			case 'O', '*':
+				cs = append(cs, cell{x, y, 1})
+			default:
+				return nil, fmt.Errorf("invalid cell '%c'", c)
+			}
+		}
+		y++
+	}
+
+	p, err := newPattern(cs, 0, y, false)
+	if err != nil {
+		return nil, err
+	}
+	p.Name, p.Comments = name, comments
+
+	return p, nil
+}
+
+func ReadLife106(r io.Reader) (*Pattern, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "#Life 1.06") {
+		return nil, fmt.Errorf("missing '#Life 1.06' header")
+	}
+
+	var name, rule string
+	var comments []string
+	var cs []cell
+
+	for _, line := range lines[1:] {
+		switch {
+		case strings.HasPrefix(line, "#N"):
+			name = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, "#D"):
+			comments = append(comments, strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, "#R"):
+			rule = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, "#"), line == "":
+		default:
+			var c cell
+			if _, err := fmt.Sscanf(line, "%d %d", &c.x, &c.y); err != nil {
+				return nil, fmt.Errorf("invalid cell '%s'", line)
+			}
+			c.state = 1
+			cs = append(cs, c)
+		}
+	}
+
+	p, err := newPattern(cs, 0, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	p.Name, p.Comments, p.Rule = name, comments, rule
+
+	return p, nil
+}
+
+// ReadMCell reads patterns of MCell, of which the rule is given by #RULE, or
+// by #GAME for games without rules such as WireWorld.
+func ReadMCell(r io.Reader) (*Pattern, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "#MCell") {
+		return nil, fmt.Errorf("missing '#MCell' header")
+	}
+
+	var game, rule string
+	var comments []string
+	var body strings.Builder
+
+	for _, line := range lines[1:] {
+		switch {
+		case strings.HasPrefix(line, "#L"):
+			body.WriteString(strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, "#GAME"):
+			game = strings.TrimSpace(line[5:])
+		case strings.HasPrefix(line, "#RULE"):
+			rule = strings.TrimSpace(line[5:])
+		case strings.HasPrefix(line, "#D"):
+			comments = append(comments, strings.TrimSpace(line[2:]))
+		}
+	}
+
+	cs, _, err := readRuns(body.String())
+	if err != nil {
+		return nil, err
+	}
+	if rule == "" {
+		rule = game
+	}
+
+	p, err := newPattern(cs, 0, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	p.Comments, p.Rule = comments, rule
+
+	return p, nil
+}
+
+// Wireworld states, as numbered by Golly.
+const (
+	WireworldEmpty = iota
+	WireworldHead
+	WireworldTail
+	WireworldConductor
+)
+
+// ReadWireworld reads Wireworld circuits drawn in text, with spaces for
+// empty cells, # for conductors, H for electron heads and t for electron
+// tails. Lines starting with ! are comments.
+func ReadWireworld(r io.Reader) (*Pattern, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []string
+	var cs []cell
+	y := 0
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "!") {
+			comments = append(comments, strings.TrimSpace(line[1:]))
+			continue
+		}
+		for x, c := range line {
+			s := WireworldEmpty
+			switch c {
+			case ' ':
+			case '#':
+				s = WireworldConductor
+			case 'H':
+				s = WireworldHead
+			case 't':
+				s = WireworldTail
+			default:
+				return nil, fmt.Errorf("invalid cell '%c'", c)
+			}
+			if s != WireworldEmpty {
+				cs = append(cs, cell{x, y, s})
+			}
+		}
+		y++
+	}
+
+	p, err := newPattern(cs, 0, y, false)
+	if err != nil {
+		return nil, err
+	}
+	p.Comments, p.Rule = comments, "WireWorld"
+
+	return p, nil
+}
+
+// Read reads a pattern in any of the supported formats, guessed from its
+// first lines.
+func Read(r io.Reader) (*Pattern, error) {
+	br := bufio.NewReader(r)
+
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("empty pattern")
+		}
+		if b[0] != '\n' && b[0] != '\r' {
+			break
+		}
+		br.ReadByte()
+	}
+
+	if b, _ := br.Peek(10); string(b) == "#Life 1.06" {
+		return ReadLife106(br)
+	}
+	if b, _ := br.Peek(6); string(b) == "#MCell" {
+		return ReadMCell(br)
+	}
+	if b, _ := br.Peek(1); b[0] == '#' || b[0] == 'x' {
+		return ReadRLE(br)
+	}
+	return ReadPlaintext(br)
+}
+
+// Load reads the pattern file at path, its format being given by its
+// extension: .rle, .cells, .lif, .mcl or .wire (Wireworld circuits), or
+// guessed otherwise.
+func Load(path string) (*Pattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var p *Pattern
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".rle":
+		p, err = ReadRLE(f)
+	case ".cells":
+		p, err = ReadPlaintext(f)
+	case ".lif", ".life":
+		p, err = ReadLife106(f)
+	case ".mcl":
+		p, err = ReadMCell(f)
+	case ".wire":
+		p, err = ReadWireworld(f)
+	default:
+		p, err = Read(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return p, nil
+}
@@ -0,0 +1,185 @@
+package pattern
+
+import (
+	"strings"
+	"testing"
+)
+
+var glider = []int{
+	0, 1, 0,
+	0, 0, 1,
+	1, 1, 1,
+}
+
+func testCells(t *testing.T, p *Pattern, width, height int, want []int) {
+	if p.Width != width || p.Height != height {
+		t.Fatalf("size = %dx%d; want %dx%d", p.Width, p.Height, width,
+			height)
+	}
+	for i, s := range p.Cells {
+		if s != want[i] {
+			t.Errorf("cell[%d] = %d; want %d", i, s, want[i])
+		}
+	}
+}
+
+func TestReadRLE(t *testing.T) {
+	p, err := ReadRLE(strings.NewReader(`#N Glider
+#C A small spaceship.
+x = 3, y = 3, rule = B3/S23
+bo$2bo$
+3o!`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Glider" || p.Rule != "B3/S23" || len(p.Comments) != 1 {
+		t.Errorf("name = %s, rule = %s, comments = %v", p.Name, p.Rule,
+			p.Comments)
+	}
+	testCells(t, p, 3, 3, glider)
+}
+
+func TestReadRLEStates(t *testing.T) {
+	p, err := ReadRLE(strings.NewReader(`x = 4, y = 3
+.A2B$$pAqX.!`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCells(t, p, 4, 3, []int{
+		0, 1, 2, 2,
+		0, 0, 0, 0,
+		25, 72, 0, 0,
+	})
+	if n := p.States(); n != 73 {
+		t.Errorf("states = %d; want 73", n)
+	}
+}
+
+func TestReadRLEErrors(t *testing.T) {
+	for _, str := range [...]string{
+		"bo$2bo$3o!",
+		"x = 3, y = 3\nbo$2bo$3o",
+		"x = 3, y = 3\nbo$2bz$3o!",
+		"x = a, y = 3\nbo!",
+		"x = 3000000000, y = 3000000000\nbo!",
+		"x = 3, y = 3\n3000000000o!",
+		"x = 3, y = 3\n1000$1000$o!",
+	} {
+		if _, err := ReadRLE(strings.NewReader(str)); err == nil {
+			t.Errorf("ReadRLE(%q) succeeded", str)
+		}
+	}
+}
+
+func TestReadPlaintext(t *testing.T) {
+	p, err := ReadPlaintext(strings.NewReader(`!Name: Glider
+!
+.O
+..O
+OOO`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Glider" {
+		t.Errorf("name = %s; want Glider", p.Name)
+	}
+	testCells(t, p, 3, 3, glider)
+
+	if _, err := ReadPlaintext(strings.NewReader(".O\nx")); err == nil {
+		t.Error("ReadPlaintext succeeded with an invalid cell")
+	}
+}
+
+func TestReadLife106(t *testing.T) {
+	p, err := ReadLife106(strings.NewReader(`#Life 1.06
+#D A glider
+0 -1
+1 0
+-1 1
+0 1
+1 1`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCells(t, p, 3, 3, glider)
+
+	if _, err := ReadLife106(strings.NewReader("0 1")); err == nil {
+		t.Error("ReadLife106 succeeded without header")
+	}
+	far := "#Life 1.06\n-9223372036854775808 0\n9223372036854775807 0"
+	if _, err := ReadLife106(strings.NewReader(far)); err == nil {
+		t.Error("ReadLife106 succeeded with cells far apart")
+	}
+}
+
+func TestReadMCell(t *testing.T) {
+	p, err := ReadMCell(strings.NewReader(`#MCell 4.20
+#GAME WireWorld
+#BOARD 80x60
+#D A diode.
+#L .2C$BA2C$.2C`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rule != "WireWorld" || len(p.Comments) != 1 {
+		t.Errorf("rule = %s, comments = %v", p.Rule, p.Comments)
+	}
+	testCells(t, p, 4, 3, []int{
+		0, 3, 3, 0,
+		2, 1, 3, 3,
+		0, 3, 3, 0,
+	})
+
+	p, err = ReadMCell(strings.NewReader(
+		"#MCell 4.20\n#GAME Life\n#RULE 23/3\n#L .A$2.A$3A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rule != "23/3" {
+		t.Errorf("rule = %s; want 23/3", p.Rule)
+	}
+	testCells(t, p, 3, 3, glider)
+
+	if _, err := ReadMCell(strings.NewReader("#L 3A")); err == nil {
+		t.Error("ReadMCell succeeded without header")
+	}
+}
+
+func TestReadWireworld(t *testing.T) {
+	p, err := ReadWireworld(strings.NewReader(`! A clock.
+tH#
+# #
+###`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rule != "WireWorld" || len(p.Comments) != 1 {
+		t.Errorf("rule = %s, comments = %v", p.Rule, p.Comments)
+	}
+	testCells(t, p, 3, 3, []int{
+		WireworldTail, WireworldHead, WireworldConductor,
+		WireworldConductor, WireworldEmpty, WireworldConductor,
+		WireworldConductor, WireworldConductor, WireworldConductor,
+	})
+
+	if _, err := ReadWireworld(strings.NewReader("#O#")); err == nil {
+		t.Error("ReadWireworld succeeded with an invalid cell")
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, str := range [...]string{
+		"\n#N Glider\nx = 3, y = 3\nbo$2bo$3o!",
+		"x = 3, y = 3\nbo$2bo$3o!",
+		"!Name: Glider\n.O\n..O\nOOO",
+		".O\n..O\nOOO",
+		"#Life 1.06\n0 -1\n1 0\n-1 1\n0 1\n1 1",
+		"#MCell 4.20\n#L .A$2.A$3A",
+	} {
+		p, err := Read(strings.NewReader(str))
+		if err != nil {
+			t.Fatalf("Read(%q): %v", str, err)
+		}
+		testCells(t, p, 3, 3, glider)
+	}
+}
@@ -0,0 +1,96 @@
+package pattern
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Lines of RLE bodies are wrapped at this length.
+const maxLineLength = 70
+
+// stateTag returns the RLE tag of a state, using b and o for two-state
+// patterns.
+func stateTag(s int, multi bool) string {
+	switch {
+	case !multi && s == 0:
+		return "b"
+	case !multi:
+		return "o"
+	case s == 0:
+		return "."
+	case s <= 24:
+		return string(rune('A' + s - 1))
+	}
+	q, r := (s-1)/24, (s-1)%24
+	return string([]rune{rune('p' + q - 1), rune('A' + r)})
+}
+
+type rleWriter struct {
+	w    *bufio.Writer
+	line int
+}
+
+func (rw *rleWriter) token(n int, tag string) {
+	t := tag
+	if n > 1 {
+		t = strconv.Itoa(n) + tag
+	}
+	if rw.line+len(t) > maxLineLength {
+		rw.w.WriteString("\n")
+		rw.line = 0
+	}
+	rw.w.WriteString(t)
+	rw.line += len(t)
+}
+
+// WriteRLE writes a pattern in the RLE format, using the multi-state tags
+// if it has more than two states.
+func WriteRLE(w io.Writer, p *Pattern) error {
+	bw := bufio.NewWriter(w)
+	rw := &rleWriter{w: bw}
+	multi := p.States() > 2
+
+	if p.Name != "" {
+		fmt.Fprintf(bw, "#N %s\n", p.Name)
+	}
+	for _, c := range p.Comments {
+		fmt.Fprintf(bw, "#C %s\n", c)
+	}
+	fmt.Fprintf(bw, "x = %d, y = %d", p.Width, p.Height)
+	if p.Rule != "" {
+		fmt.Fprintf(bw, ", rule = %s", p.Rule)
+	}
+	bw.WriteString("\n")
+
+	rows := 0
+	for y := 0; y < p.Height; y++ {
+		row := p.Cells[y*p.Width : (y+1)*p.Width]
+		end := len(row)
+		for end > 0 && row[end-1] == 0 {
+			end--
+		}
+		if end == 0 {
+			rows++
+			continue
+		}
+		if rows > 0 {
+			rw.token(rows, "$")
+		}
+
+		for x := 0; x < end; {
+			n := 1
+			for x+n < end && row[x+n] == row[x] {
+				n++
+			}
+			rw.token(n, stateTag(row[x], multi))
+			x += n
+		}
+		rows = 1
+	}
+	rw.token(1, "!")
+	bw.WriteString("\n")
+
+	return bw.Flush()
+}
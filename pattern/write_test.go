@@ -0,0 +1,62 @@
+package pattern
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testWriteRLE(t *testing.T, p *Pattern, want string) {
+	var b bytes.Buffer
+	if err := WriteRLE(&b, p); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != want {
+		t.Errorf("WriteRLE = %q; want %q", b.String(), want)
+	}
+
+	q, err := ReadRLE(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCells(t, q, p.Width, p.Height, p.Cells)
+}
+
+func TestWriteRLE(t *testing.T) {
+	p := &Pattern{Name: "Glider", Rule: "B3/S23", Width: 3, Height: 3,
+		Cells: glider}
+	testWriteRLE(t, p, "#N Glider\nx = 3, y = 3, rule = B3/S23\n"+
+		"bo$2bo$3o!\n")
+}
+
+func TestWriteRLEStates(t *testing.T) {
+	p := &Pattern{Width: 4, Height: 4, Cells: []int{
+		0, 1, 2, 2,
+		0, 0, 0, 0,
+		0, 0, 0, 0,
+		25, 72, 0, 0,
+	}}
+	testWriteRLE(t, p, "x = 4, y = 4\n.A2B3$pAqX!\n")
+}
+
+func TestWriteRLEWrap(t *testing.T) {
+	cells := make([]int, 200)
+	for i := range cells {
+		cells[i] = i % 2
+	}
+	p := &Pattern{Width: 200, Height: 1, Cells: cells}
+
+	var b bytes.Buffer
+	WriteRLE(&b, p)
+	for _, line := range strings.Split(b.String(), "\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line length = %d; want <= %d", len(line),
+				maxLineLength)
+		}
+	}
+	q, err := ReadRLE(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCells(t, q, 200, 1, cells)
+}